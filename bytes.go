@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// isByteSlice returns true for []byte and named types of it, which are decoded from a single string instead of one value per element.
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// decodeBytes decodes strVal according to encoding. An empty encoding denotes base64.
+func decodeBytes(strVal, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", encodingBase64:
+		return decodeBase64(strVal)
+
+	case encodingHex:
+		data, err := hex.DecodeString(strings.TrimSpace(strVal))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode hex string: %s", err.Error())
+		}
+		return data, nil
+
+	case encodingRaw:
+		return []byte(strVal), nil
+
+	case encodingPEM:
+		block, _ := pem.Decode([]byte(strVal))
+		if block == nil && strings.Contains(strVal, `\n`) {
+			// environment variables often contain escaped line breaks
+			block, _ = pem.Decode([]byte(strings.ReplaceAll(strVal, `\n`, "\n")))
+		}
+		if block == nil {
+			return nil, fmt.Errorf("no PEM block found")
+		}
+		return block.Bytes, nil
+
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// decodeBase64 accepts the standard and URL alphabet with or without padding.
+func decodeBase64(strVal string) ([]byte, error) {
+	strVal = strings.TrimSpace(strVal)
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(strVal, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(strVal, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+
+	data, err := encoding.DecodeString(strVal)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode base64 string: %s", err.Error())
+	}
+	return data, nil
+}
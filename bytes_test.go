@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bytesTestPEM = `-----BEGIN PUBLIC KEY-----
+AQID/w==
+-----END PUBLIC KEY-----`
+
+func TestDecodeBytes(t *testing.T) {
+	testCases := []struct {
+		Str, Encoding string
+		Expected      []byte
+	}{
+		{"AQID/w==", "", []byte{1, 2, 3, 255}},
+		{"AQID/w", "", []byte{1, 2, 3, 255}},
+		{"AQID_w==", encodingBase64, []byte{1, 2, 3, 255}},
+		{"AQID_w", encodingBase64, []byte{1, 2, 3, 255}},
+		{"", "", []byte{}},
+		{"010203ff", encodingHex, []byte{1, 2, 3, 255}},
+		{"010203FF", encodingHex, []byte{1, 2, 3, 255}},
+		{"foo bar", encodingRaw, []byte("foo bar")},
+		{bytesTestPEM, encodingPEM, []byte{1, 2, 3, 255}},
+		{"-----BEGIN PUBLIC KEY-----\\nAQID/w==\\n-----END PUBLIC KEY-----", encodingPEM, []byte{1, 2, 3, 255}},
+	}
+	for _, testCase := range testCases {
+		data, err := decodeBytes(testCase.Str, testCase.Encoding)
+		if assert.NoError(t, err, testCase.Str) {
+			assert.Equal(t, testCase.Expected, data, testCase.Str)
+		}
+	}
+}
+
+func TestDecodeBytesErrors(t *testing.T) {
+	_, err := decodeBytes("AQID/w_=", "")
+	assert.Error(t, err)
+	_, err = decodeBytes("0102x", encodingHex)
+	assert.Error(t, err)
+	_, err = decodeBytes("AQID/w==", encodingPEM)
+	assert.EqualError(t, err, "no PEM block found")
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that is configured with units like 512KiB, 10MB or 1.5GiB.
+//
+// Decimal units (KB, MB, ...) are powers of 1000, binary units (KiB, MiB, ...) are powers of 1024. Use the config tag option unit:bytes to parse integer fields the same way.
+type ByteSize int64
+
+// Units for ByteSize values.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+	EiB ByteSize = 1024 * PiB
+)
+
+type byteSizeUnit struct {
+	Name string
+	Size ByteSize
+}
+
+var (
+	// byteSizeUnits is sorted by size in descending order to find the largest exact unit first.
+	byteSizeUnits = []byteSizeUnit{
+		{"EiB", EiB}, {"EB", EB}, {"PiB", PiB}, {"PB", PB}, {"TiB", TiB}, {"TB", TB},
+		{"GiB", GiB}, {"GB", GB}, {"MiB", MiB}, {"MB", MB}, {"KiB", KiB}, {"KB", KB},
+	}
+
+	byteSizePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+)
+
+// ParseByteSize parses a number of bytes with an optional unit like 512KiB, 10MB, 1.5GiB or 42.
+func ParseByteSize(str string) (ByteSize, error) {
+	matches := byteSizePattern.FindStringSubmatch(strings.TrimSpace(str))
+	if matches == nil {
+		return 0, fmt.Errorf("cannot parse byte size from %q", str)
+	}
+
+	unit, ok := findByteSizeUnit(matches[2])
+	if !ok {
+		return 0, fmt.Errorf("cannot parse byte size from %q: unknown unit %q", str, matches[2])
+	}
+
+	num, ok := new(big.Rat).SetString(matches[1])
+	if !ok {
+		return 0, fmt.Errorf("cannot parse byte size from %q", str)
+	}
+	num.Mul(num, new(big.Rat).SetInt64(int64(unit)))
+	if !num.IsInt() {
+		return 0, fmt.Errorf("cannot parse byte size from %q: not a whole number of bytes", str)
+	}
+	if !num.Num().IsInt64() {
+		return 0, fmt.Errorf("cannot parse byte size from %q: value out of range", str)
+	}
+	return ByteSize(num.Num().Int64()), nil
+}
+
+func findByteSizeUnit(name string) (ByteSize, bool) {
+	switch strings.ToLower(name) {
+	case "", "b":
+		return Byte, true
+	}
+	for _, unit := range byteSizeUnits {
+		// also accept short forms like "k" or "Ki"
+		lowerName := strings.ToLower(unit.Name)
+		if strings.EqualFold(name, unit.Name) || strings.EqualFold(name, strings.TrimSuffix(lowerName, "b")) {
+			return unit.Size, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the size in the largest unit that represents it exactly, e.g. 512KiB or 1536B.
+func (s ByteSize) String() string {
+	if s != 0 {
+		for _, unit := range byteSizeUnits {
+			if s%unit.Size == 0 {
+				return strconv.FormatInt(int64(s/unit.Size), 10) + unit.Name
+			}
+		}
+	}
+	return strconv.FormatInt(int64(s), 10) + "B"
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	testCases := map[string]ByteSize{
+		"0":        0,
+		"42":       42,
+		"42B":      42,
+		"512KiB":   512 * KiB,
+		"512 kib":  512 * KiB,
+		"10MB":     10 * MB,
+		"10m":      10 * MB,
+		"1.5GiB":   1536 * MiB,
+		"1.5Gi":    1536 * MiB,
+		"0.5KB":    500,
+		"7EiB":     7 * EiB,
+		"1TB":      TB,
+		" 2 PiB ":  2 * PiB,
+		"1.25 KiB": 1280,
+	}
+
+	for str, expected := range testCases {
+		size, err := ParseByteSize(str)
+		if assert.NoError(t, err, str) {
+			assert.Equal(t, expected, size, str)
+		}
+	}
+
+	for _, str := range []string{"", "KiB", "-1", "1.5", "0.1KiB", "10XB", "1,5MB", "8EiB"} {
+		_, err := ParseByteSize(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "1536B", ByteSize(1536).String())
+	assert.Equal(t, "512KiB", (512 * KiB).String())
+	assert.Equal(t, "10MB", (10 * MB).String())
+	assert.Equal(t, "1500MiB", (1500 * MiB).String())
+	assert.Equal(t, "1000KiB", ByteSize(1024000).String())
+	assert.Equal(t, "-2GiB", (-2 * GiB).String())
+}
+
+type ByteSizeTest struct {
+	Buffer    ByteSize
+	CacheSize int      `config:"unit:bytes,default:1.5MiB"`
+	Limit     ByteSize `config:"default:10MB,max:1GB"`
+}
+
+func TestByteSizeFromEnvironment(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_BUFFER"] = "512KiB"
+
+		var conf ByteSizeTest
+		require.NoError(t, FromEnvironment("test", &conf))
+		assert.Equal(t, 512*KiB, conf.Buffer)
+		assert.Equal(t, 1536*1024, conf.CacheSize)
+		assert.Equal(t, 10*MB, conf.Limit)
+
+		env["TEST_LIMIT"] = "2GB"
+		assert.Error(t, FromEnvironment("test", &conf))
+	})
+}
+
+func TestByteSizeFromJSON(t *testing.T) {
+	var conf ByteSizeTest
+	require.NoError(t, FromJSON([]byte(`{"Buffer":4096,"CacheSize":"2KiB","Limit":"1.5KB"}`), &conf))
+	assert.Equal(t, 4*KiB, conf.Buffer)
+	assert.Equal(t, 2048, conf.CacheSize)
+	assert.Equal(t, ByteSize(1500), conf.Limit)
+}
+
+func TestByteSizeToString(t *testing.T) {
+	conf := ByteSizeTest{512 * KiB, 1024 * 1024, 10 * MB}
+	assert.Equal(t, "Stuff.Buffer:    512KiB\nStuff.CacheSize: 1MiB\nStuff.Limit:     10MB", ToString("Stuff", conf))
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	layoutUnix      = "unix"
+	layoutUnixMilli = "unixms"
+)
+
+var (
+	// namedLayouts can be referenced by name in the layout tag option.
+	namedLayouts = map[string]string{
+		"ANSIC":       time.ANSIC,
+		"UnixDate":    time.UnixDate,
+		"RubyDate":    time.RubyDate,
+		"RFC822":      time.RFC822,
+		"RFC822Z":     time.RFC822Z,
+		"RFC850":      time.RFC850,
+		"RFC1123":     time.RFC1123,
+		"RFC1123Z":    time.RFC1123Z,
+		"RFC3339":     time.RFC3339,
+		"RFC3339Nano": time.RFC3339Nano,
+		"Kitchen":     time.Kitchen,
+		"DateTime":    "2006-01-02 15:04:05",
+		"DateOnly":    "2006-01-02",
+		"TimeOnly":    "15:04:05",
+	}
+
+	iso8601Pattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})(?:[Tt ](\d{2}):(\d{2})(?::(\d{2})(?:[.,](\d{1,9}))?)?)?\s*([Zz]|[+-]\d{2}(?::?\d{2})?)?$`)
+	unixPattern    = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+)
+
+// parseDateTime parses a point in time according to layout. The default layout "" accepts RFC 3339 and the ISO 8601 extended format with optional time and time zone.
+//
+// Values without time zone are interpreted in loc. The layouts "unix" and "unixms" denote seconds or milliseconds since the unix epoch.
+func parseDateTime(strVal, layout string, loc *time.Location) (time.Time, error) {
+	strVal = strings.TrimSpace(strVal)
+	if namedLayout, ok := namedLayouts[layout]; ok {
+		layout = namedLayout
+	}
+
+	switch layout {
+	case "":
+		return parseISO8601(strVal, loc)
+	case layoutUnix:
+		return parseUnix(strVal, time.Second, loc)
+	case layoutUnixMilli:
+		return parseUnix(strVal, time.Millisecond, loc)
+	default:
+		return time.ParseInLocation(layout, strVal, loc)
+	}
+}
+
+func parseISO8601(strVal string, loc *time.Location) (time.Time, error) {
+	matches := iso8601Pattern.FindStringSubmatch(strVal)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("invalid format")
+	}
+
+	values := make([]int, 6)
+	for i := range values {
+		if len(matches[i+1]) > 0 {
+			values[i], _ = strconv.Atoi(matches[i+1])
+		}
+	}
+	nanos := 0
+	if fraction := matches[7]; len(fraction) > 0 {
+		nanos, _ = strconv.Atoi(fraction + strings.Repeat("0", 9-len(fraction)))
+	}
+
+	if values[1] < 1 || values[1] > 12 || values[2] < 1 || values[3] > 23 || values[4] > 59 || values[5] > 59 {
+		return time.Time{}, fmt.Errorf("value out of range")
+	}
+	dt := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], nanos, loc)
+	if dt.Day() != values[2] {
+		// time.Date normalizes invalid days like February 30th
+		return time.Time{}, fmt.Errorf("day out of range")
+	}
+
+	zone := matches[8]
+	if len(zone) == 0 {
+		return dt, nil
+	}
+	if zone == "Z" || zone == "z" {
+		return time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], nanos, time.UTC), nil
+	}
+
+	digits := strings.ReplaceAll(zone[1:], ":", "")
+	hours, _ := strconv.Atoi(digits[:2])
+	minutes := 0
+	if len(digits) == 4 {
+		minutes, _ = strconv.Atoi(digits[2:])
+	}
+	if hours > 23 || minutes > 59 {
+		return time.Time{}, fmt.Errorf("time zone offset out of range")
+	}
+	offset := hours*3600 + minutes*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+
+	// interpret as UTC and subtract offset to get the actual point in time
+	dt = time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], nanos, time.UTC).Add(-time.Duration(offset) * time.Second)
+	// keep the configured location if it has the given offset, like time.ParseInLocation
+	if _, locOffset := dt.In(loc).Zone(); locOffset == offset {
+		return dt.In(loc), nil
+	}
+	if offset == 0 {
+		return dt, nil
+	}
+	return dt.In(time.FixedZone("", offset)), nil
+}
+
+func parseUnix(strVal string, unit time.Duration, loc *time.Location) (time.Time, error) {
+	if !unixPattern.MatchString(strVal) {
+		return time.Time{}, fmt.Errorf("invalid unix timestamp")
+	}
+	num, _ := new(big.Rat).SetString(strVal)
+	num.Mul(num, new(big.Rat).SetInt64(int64(unit)))
+	nanos := new(big.Int).Quo(num.Num(), num.Denom())
+
+	seconds, remainder := new(big.Int).QuoRem(nanos, big.NewInt(int64(time.Second)), new(big.Int))
+	if !seconds.IsInt64() {
+		return time.Time{}, fmt.Errorf("unix timestamp out of range")
+	}
+	return time.Unix(seconds.Int64(), remainder.Int64()).In(loc), nil
+}
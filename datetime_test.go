@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDateTimeISO8601(t *testing.T) {
+	plusTwo := time.FixedZone("", 2*3600)
+	testCases := map[string]time.Time{
+		"2020-02-17":                       time.Date(2020, time.February, 17, 0, 0, 0, 0, time.UTC),
+		"2020-02-17T09:06":                 time.Date(2020, time.February, 17, 9, 6, 0, 0, time.UTC),
+		"2020-02-17 09:06:21.5":            time.Date(2020, time.February, 17, 9, 6, 21, 500000000, time.UTC),
+		"2020-02-17t09:06:21,123456789z":   time.Date(2020, time.February, 17, 9, 6, 21, 123456789, time.UTC),
+		"2020-02-17T09:06:21.25+02:00":     time.Date(2020, time.February, 17, 9, 6, 21, 250000000, plusTwo),
+		"2020-02-17T09:06:21+0200":         time.Date(2020, time.February, 17, 9, 6, 21, 0, plusTwo),
+		"2020-02-17T09:06:21+02":           time.Date(2020, time.February, 17, 9, 6, 21, 0, plusTwo),
+		"2020-02-17T09:06:21-00:00":        time.Date(2020, time.February, 17, 9, 6, 21, 0, time.UTC),
+		"2020-02-29T23:59:59Z":             time.Date(2020, time.February, 29, 23, 59, 59, 0, time.UTC),
+		" 2020-02-17T09:06:21.000000001Z ": time.Date(2020, time.February, 17, 9, 6, 21, 1, time.UTC),
+	}
+	for str, expected := range testCases {
+		dt, err := parseDateTime(str, "", time.UTC)
+		if assert.NoError(t, err, str) {
+			assert.True(t, expected.Equal(dt), "%s: expected %s, got %s", str, expected, dt)
+			_, expectedOffset := expected.Zone()
+			_, offset := dt.Zone()
+			assert.Equal(t, expectedOffset, offset, str)
+		}
+	}
+}
+
+func TestParseDateTimeLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	dt, err := parseDateTime("2020-07-01 12:00:00", "", berlin)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.July, 1, 10, 0, 0, 0, time.UTC), dt.UTC())
+		assert.Equal(t, berlin, dt.Location())
+	}
+
+	// explicit offsets matching the location keep it
+	dt, err = parseDateTime("2020-07-01T12:00:00+02:00", "", berlin)
+	if assert.NoError(t, err) {
+		assert.Equal(t, berlin, dt.Location())
+	}
+}
+
+func TestParseDateTimeLayouts(t *testing.T) {
+	dt, err := parseDateTime("17.02.2020 09:06", "02.01.2006 15:04", time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 0, 0, time.UTC), dt)
+	}
+
+	dt, err = parseDateTime("Mon, 17 Feb 2020 09:06:21 GMT", "RFC1123", time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 0, time.UTC), dt.UTC())
+	}
+
+	dt, err = parseDateTime("1581930381", layoutUnix, time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 0, time.UTC), dt)
+	}
+
+	dt, err = parseDateTime("1581930381.5", layoutUnix, time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 500000000, time.UTC), dt)
+	}
+
+	dt, err = parseDateTime("1581930381250", layoutUnixMilli, time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 250000000, time.UTC), dt)
+	}
+
+	dt, err = parseDateTime("-1000", layoutUnixMilli, time.UTC)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(1969, time.December, 31, 23, 59, 59, 0, time.UTC), dt)
+	}
+}
+
+func TestParseDateTimeErrors(t *testing.T) {
+	for _, str := range []string{"", "2020-2-17", "2020-02-30", "2020-13-01", "2020-02-17T24:00:00", "2020-02-17T09:60", "2020-02-17T09:06:21+25:00", "2020-02-17X09:06", "17.02.2020"} {
+		_, err := parseDateTime(str, "", time.UTC)
+		assert.Error(t, err, str)
+	}
+
+	for _, str := range []string{"", "1e9", "12ab", "99999999999999999999"} {
+		_, err := parseDateTime(str, layoutUnix, time.UTC)
+		assert.Error(t, err, str)
+	}
+}
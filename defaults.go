@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyDefaults assigns the default values from config tags to all fields of conf that have the zero value.
+//
+// Defaults are parsed like values from environment variables. Nil pointers are only allocated if a default value is assigned to them or their fields. Hooks are not called.
+func ApplyDefaults(conf interface{}, opts ...Option) error {
+	dst := newObject(conf)
+	if !dst.IsAssignable() {
+		return fmt.Errorf("conf must be an assignable value")
+	}
+	ctx := newLoadContext(opts)
+	ctx.NoHooks = true
+	ctx.TrackReferences(dst.t)
+	return applyDefaults(ctx, newPathPrefix(""), dst, nil)
+}
+
+// applyDefaults assigns the default value of tag to dst if it is zero and descends into structs, pointers, slices and arrays. Loaders use it for values missing in the configuration, so hooks of structs are called unless ctx.NoHooks is set.
+func applyDefaults(ctx *loadContext, prefix pathPrefix, dst *object, tag *tag) error {
+	if setter := dst.StringSetter(tag); setter != nil {
+		if strVal, ok := ctx.Default(tag); ok && dst.v.IsZero() {
+			return assignString(ctx, prefix, setter, strVal)
+		}
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.v.IsNil() {
+			if !dst.v.CanSet() {
+				return nil
+			}
+			val := &object{dst.t, reflect.New(dst.t.Elem())}
+			assignCount := ctx.AssignCount
+			if err := applyDefaults(ctx, prefix, val.Elem(), tag); err != nil {
+				return err
+			}
+			if ctx.AssignCount > assignCount {
+				dst.v.Set(val.v)
+			}
+			return nil
+		}
+		return applyDefaults(ctx, prefix, dst.Elem(), tag)
+
+	case reflect.Struct:
+		return applyStructDefaults(ctx, prefix, dst)
+
+	case reflect.Slice, reflect.Array:
+		return dst.IterateArray(func(i int, dst *object) error {
+			return applyDefaults(ctx, prefix.Index(i), dst, nil)
+		})
+	}
+	return nil
+}
+
+func applyStructDefaults(ctx *loadContext, prefix pathPrefix, dst *object) error {
+	if !ctx.NoHooks {
+		callSetDefaults(dst)
+	}
+	releaseInline := dst.AllocInline()
+	if err := dst.IterateStruct(func(dst *object, tag *tag) error {
+		if dst.IsAssignable() {
+			return applyDefaults(ctx, prefix.Field(tag.FieldName), dst, tag)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	releaseInline()
+	if ctx.NoHooks {
+		return nil
+	}
+	return callAfterLoad(prefix, dst)
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DefaultsTest struct {
+	Name     string        `config:"default:app"`
+	Port     int           `config:"default:8080"`
+	Timeout  time.Duration `config:"default:1m 30s"`
+	Buffer   ByteSize      `config:"default:4KiB"`
+	Level    EnumTestLevel `config:"default:warn"`
+	Set      string        `config:"default:unused"`
+	Nested   EnvTestDefault
+	Optional *EnvTestDefault
+	Missing  *EnvTestSimple
+	Items    []EnvTestDefault
+}
+
+func TestApplyDefaults(t *testing.T) {
+	conf := DefaultsTest{Set: "explicit", Items: make([]EnvTestDefault, 1)}
+	if assert.NoError(t, ApplyDefaults(&conf)) {
+		assert.Equal(t, "app", conf.Name)
+		assert.Equal(t, 8080, conf.Port)
+		assert.Equal(t, 90*time.Second, conf.Timeout)
+		assert.Equal(t, 4*KiB, conf.Buffer)
+		assert.Equal(t, EnumTestWarn, conf.Level)
+		assert.Equal(t, "explicit", conf.Set)
+		assert.Equal(t, EnvTestDefault{"foobar", 42, true, false}, conf.Nested)
+		assert.Equal(t, &EnvTestDefault{"foobar", 42, true, false}, conf.Optional)
+		assert.Nil(t, conf.Missing)
+		assert.Equal(t, []EnvTestDefault{{"foobar", 42, true, false}}, conf.Items)
+	}
+
+	assert.Error(t, ApplyDefaults(conf))
+}
+
+type DefaultsTestInvalid struct {
+	Port int `config:"default:http"`
+}
+
+func TestApplyDefaultsInvalid(t *testing.T) {
+	var conf DefaultsTestInvalid
+	assert.EqualError(t, ApplyDefaults(&conf), `Port: cannot parse int from "http"`)
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	enumsMutex sync.RWMutex
+	enums      = make(map[reflect.Type]*enumType)
+)
+
+type enumType struct {
+	// Names contains all registered names ordered by value.
+	Names []string
+	// Values maps lower case names to values.
+	Values map[string]reflect.Value
+	// ValueNames maps values to the name that is used for printing.
+	ValueNames map[interface{}]string
+}
+
+// RegisterEnum registers names for the values of a type, so they can be configured by name instead of their underlying value. The argument must be a map from names to values of the enum type, e.g. map[string]Level.
+//
+// Names are matched case-insensitively. If multiple names map to the same value, the first one in alphabetical order is used for printing. Registering a type again replaces all names. RegisterEnum panics on invalid arguments and is meant to be called in init functions.
+func RegisterEnum(names interface{}) {
+	v := reflect.ValueOf(names)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		panic(fmt.Sprintf("config.RegisterEnum: expected map from names to values, but got %T", names))
+	}
+	t := v.Type().Elem()
+	if !t.Comparable() || t.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("config.RegisterEnum: enum type %s must be a comparable concrete type", t))
+	}
+	if v.Len() == 0 {
+		panic(fmt.Sprintf("config.RegisterEnum: no names given for enum type %s", t))
+	}
+
+	enum := &enumType{
+		Names:      make([]string, 0, v.Len()),
+		Values:     make(map[string]reflect.Value),
+		ValueNames: make(map[interface{}]string),
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		name := key.String()
+		lowerName := strings.ToLower(name)
+		if len(name) == 0 {
+			panic(fmt.Sprintf("config.RegisterEnum: empty name for enum type %s", t))
+		}
+		if _, ok := enum.Values[lowerName]; ok {
+			panic(fmt.Sprintf("config.RegisterEnum: ambiguous name %q for enum type %s", name, t))
+		}
+		enum.Names = append(enum.Names, name)
+		enum.Values[lowerName] = v.MapIndex(key)
+	}
+
+	sort.Slice(enum.Names, func(i, j int) bool {
+		a, b := enum.Values[strings.ToLower(enum.Names[i])], enum.Values[strings.ToLower(enum.Names[j])]
+		if less, ok := lessEnumValue(a, b); ok {
+			return less
+		}
+		return enum.Names[i] < enum.Names[j]
+	})
+	for _, name := range enum.Names {
+		val := enum.Values[strings.ToLower(name)].Interface()
+		if existing, ok := enum.ValueNames[val]; !ok || name < existing {
+			enum.ValueNames[val] = name
+		}
+	}
+
+	enumsMutex.Lock()
+	defer enumsMutex.Unlock()
+	enums[t] = enum
+}
+
+// lessEnumValue compares numeric values and returns false as second result for equal or non-numeric values.
+func lessEnumValue(a, b reflect.Value) (bool, bool) {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int(), a.Int() != b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint(), a.Uint() != b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float(), a.Float() != b.Float()
+	}
+	return false, false
+}
+
+func lookupEnum(t reflect.Type) (*enumType, bool) {
+	enumsMutex.RLock()
+	defer enumsMutex.RUnlock()
+	enum, ok := enums[t]
+	return enum, ok
+}
+
+func isEnumType(t reflect.Type) bool {
+	_, ok := lookupEnum(t)
+	return ok
+}
+
+// Parse returns the value for the given name.
+func (e *enumType) Parse(name string) (reflect.Value, error) {
+	val, ok := e.Values[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown value %q, expected one of %s", name, strings.Join(e.Names, ", "))
+	}
+	return val, nil
+}
+
+// Name returns the name of a registered value.
+func (e *enumType) Name(val interface{}) (string, bool) {
+	name, ok := e.ValueNames[val]
+	return name, ok
+}
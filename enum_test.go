@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type EnumTestLevel int
+
+const (
+	EnumTestDebug EnumTestLevel = iota
+	EnumTestInfo
+	EnumTestWarn
+	EnumTestError
+)
+
+type EnumTestMode string
+
+func init() {
+	RegisterEnum(map[string]EnumTestLevel{
+		"debug":   EnumTestDebug,
+		"info":    EnumTestInfo,
+		"warn":    EnumTestWarn,
+		"warning": EnumTestWarn,
+		"error":   EnumTestError,
+	})
+}
+
+func TestEnumParse(t *testing.T) {
+	enum, ok := lookupEnum(reflect.TypeOf(EnumTestDebug))
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, []string{"debug", "info", "warn", "warning", "error"}, enum.Names)
+
+	val, err := enum.Parse("WARNING")
+	if assert.NoError(t, err) {
+		assert.Equal(t, EnumTestWarn, val.Interface())
+	}
+
+	_, err = enum.Parse("verbose")
+	assert.EqualError(t, err, `unknown value "verbose", expected one of debug, info, warn, warning, error`)
+
+	name, ok := enum.Name(EnumTestWarn)
+	assert.True(t, ok)
+	assert.Equal(t, "warn", name)
+	_, ok = enum.Name(EnumTestLevel(42))
+	assert.False(t, ok)
+}
+
+func TestRegisterEnumPanics(t *testing.T) {
+	assert.PanicsWithValue(t, "config.RegisterEnum: expected map from names to values, but got []string", func() {
+		RegisterEnum([]string{"foo"})
+	})
+	assert.PanicsWithValue(t, `config.RegisterEnum: ambiguous name "fast" for enum type config.EnumTestMode`, func() {
+		RegisterEnum(map[string]EnumTestMode{"fast": "f", "Fast": "F"})
+	})
+	assert.PanicsWithValue(t, "config.RegisterEnum: no names given for enum type config.EnumTestMode", func() {
+		RegisterEnum(map[string]EnumTestMode{})
+	})
+}
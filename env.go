@@ -2,59 +2,79 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	lookupEnv = os.LookupEnv
+	environ   = os.Environ
 
 	typeDateTime = reflect.TypeOf(time.Time{})
 	typeDuration = reflect.TypeOf(time.Duration(0))
+	typeByteSize = reflect.TypeOf(ByteSize(0))
+
+	typeURL          = reflect.TypeOf(url.URL{})
+	typeIP           = reflect.TypeOf(net.IP(nil))
+	typeIPNet        = reflect.TypeOf(net.IPNet{})
+	typeHardwareAddr = reflect.TypeOf(net.HardwareAddr(nil))
+	typeAddrPort     = reflect.TypeOf(netip.AddrPort{})
+	typeHostPort     = reflect.TypeOf(HostPort{})
 )
 
 // FromEnvironment reads all values from environment variables.
-func FromEnvironment(prefix string, conf interface{}) error {
+func FromEnvironment(prefix string, conf interface{}, opts ...Option) error {
 	dst := newObject(conf)
 	if !dst.IsAssignable() {
 		return fmt.Errorf("conf must be an assignable value")
 	}
-	return fromEnvironment(newPathPrefix(prefix), dst, nil)
+
+	ctx := newLoadContext(opts)
+	// any environment variable may reference other values
+	ctx.Resolved = make(map[string]string)
+	rootPrefix := newPathPrefix(prefix)
+	if err := fromEnvironment(ctx, rootPrefix, dst, nil); err != nil {
+		return err
+	}
+	if ctx.Options.Strict {
+		if err := checkUnknownEnvironment(ctx, rootPrefix); err != nil {
+			return err
+		}
+	}
+	if ctx.Options.NoValidation {
+		return nil
+	}
+	return validate(rootPrefix, dst, ctx.Options.EnvNaming)
 }
 
-func fromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
+func fromEnvironment(ctx *loadContext, prefix pathPrefix, dst *object, tag *tag) error {
 	//TODO custom types with interfaces
 
-	if dst.Is(typeDateTime) {
-		return dateTimeFromEnvironment(prefix, dst, tag)
-	}
-	if dst.Is(typeDuration) {
-		return durationFromEnvironment(prefix, dst, tag)
+	if setter := dst.StringSetter(tag); setter != nil {
+		return assignFromEnvOrDefault(ctx, prefix, setter, tag)
 	}
 
 	switch dst.Kind() {
 	case reflect.Ptr:
 		if dst.v.IsNil() && dst.v.CanSet() {
-			dst.v.Set(reflect.New(dst.t.Elem()))
+			return optionalFromEnvironment(ctx, prefix, dst, tag)
 		}
-		return fromEnvironment(prefix, dst.Elem(), tag)
+		return fromEnvironment(ctx, prefix, dst.Elem(), tag)
 
 	case reflect.Struct:
-		return structFromEnvironment(prefix, dst)
+		return structFromEnvironment(ctx, prefix, dst)
 
 	case reflect.Slice:
-		return sliceFromEnvironment(prefix, dst)
+		return sliceFromEnvironment(ctx, prefix, dst)
 	case reflect.Array:
-		return arrayFromEnvironment(prefix, dst)
-
-	case reflect.String:
-		return stringFromEnvironment(prefix, dst, tag)
-	case reflect.Bool:
-		return boolFromEnvironment(prefix, dst, tag)
-	case reflect.Int:
-		return intFromEnvironment(prefix, dst, tag)
+		return arrayFromEnvironment(ctx, prefix, dst)
 
 	default:
 		// just ignore unsupported types
@@ -62,76 +82,257 @@ func fromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
 	}
 }
 
-func structFromEnvironment(prefix pathPrefix, dst *object) error {
-	return dst.IterateStruct(func(dst *object, tag tag) error {
+// optionalFromEnvironment assigns a new value to the nil pointer dst only if at least one value is configured for it.
+func optionalFromEnvironment(ctx *loadContext, prefix pathPrefix, dst *object, tag *tag) error {
+	val := &object{dst.t, reflect.New(dst.t.Elem())}
+	assignCount := ctx.AssignCount
+	if err := fromEnvironment(ctx, prefix, val.Elem(), tag); err != nil {
+		return err
+	}
+	if ctx.AssignCount > assignCount {
+		dst.v.Set(val.v)
+	}
+	return nil
+}
+
+func structFromEnvironment(ctx *loadContext, prefix pathPrefix, dst *object) error {
+	callSetDefaults(dst)
+	releaseInline := dst.AllocInline()
+	if err := dst.IterateStruct(func(dst *object, tag *tag) error {
 		if dst.IsAssignable() {
-			return fromEnvironment(prefix.Field2(tag.FieldName, tag.EnvName), dst, &tag)
+			return fromEnvironment(ctx, prefix.FieldWithAliases(tag.FieldName, tag.EnvName, tag.Aliases), dst, tag)
 		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	releaseInline()
+	return callAfterLoad(prefix, dst)
 }
 
-func sliceFromEnvironment(prefix pathPrefix, dst *object) error {
-	numStrVal, ok := lookupEnv(prefix.Field("Num").Env())
-	if !ok || len(numStrVal) == 0 {
-		return nil
+func sliceFromEnvironment(ctx *loadContext, prefix pathPrefix, dst *object) error {
+	num := -1
+	numStrVal, ok, err := lookupEnvVariants(ctx, prefix.Field("Num"))
+	if err != nil {
+		return err
+	}
+	if ok && len(numStrVal) > 0 {
+		num, err = strconv.Atoi(numStrVal)
+		if err != nil {
+			return fmt.Errorf("%s: failed to parse list length from %q", prefix.String(), numStrVal)
+		}
 	}
 
-	num, err := strconv.Atoi(numStrVal)
-	if err != nil {
-		return fmt.Errorf("%s: failed to parse list length from %q", prefix.String(), numStrVal)
+	if ctx.Options.AutoSliceLength {
+		indexCount, err := sliceLengthFromEnvironment(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		if num < 0 {
+			if indexCount == 0 {
+				return nil
+			}
+			num = indexCount
+		} else if num != indexCount {
+			return fmt.Errorf("%s: list length %d does not match %d indexed entries", prefix.String(), num, indexCount)
+		}
+	}
+
+	if num < 0 {
+		return nil
 	}
 
+	ctx.AssignCount++
 	dst.InitSlice(num)
 	return dst.IterateSlice(func(i int, dst *object) error {
-		return fromEnvironment(prefix.Index(i), dst, nil)
+		return fromEnvironment(ctx, prefix.Index(i), dst, nil)
 	})
 }
 
-func arrayFromEnvironment(prefix pathPrefix, dst *object) error {
-	return dst.IterateArray(func(i int, dst *object) error {
-		return fromEnvironment(prefix.Index(i), dst, nil)
-	})
+// sliceLengthFromEnvironment returns the number of contiguous indices found in environment variables {PREFIX}_{INDEX} and {PREFIX}_{INDEX}_*. Entries of aliases are counted as well.
+func sliceLengthFromEnvironment(ctx *loadContext, prefix pathPrefix) (int, error) {
+	var keyPrefixes []string
+	for _, variant := range prefix.Variants() {
+		// the naming strategy determines how indices are appended to the prefix
+		firstKey := ctx.EnvName(variant.Path.Index(0))
+		if !strings.HasSuffix(firstKey, "0") {
+			return 0, fmt.Errorf("%s: cannot find list entries for naming %q", prefix.String(), firstKey)
+		}
+		keyPrefixes = append(keyPrefixes, strings.TrimSuffix(firstKey, "0"))
+	}
+
+	indices := make(map[int]bool)
+	maxIndex := -1
+	for _, entry := range environ() {
+		key := strings.SplitN(entry, "=", 2)[0]
+		keyPrefix, ok := findKeyPrefix(key, keyPrefixes)
+		if !ok {
+			continue
+		}
+
+		suffix := key[len(keyPrefix):]
+		digitCount := strings.IndexFunc(suffix, func(r rune) bool { return r < '0' || r > '9' })
+		if digitCount < 0 {
+			digitCount = len(suffix)
+		}
+		if digitCount < len(suffix) && isAlphanumeric(rune(suffix[digitCount])) {
+			// not followed by a separator
+			continue
+		}
+
+		index, ok := parseEnvIndex(suffix[:digitCount])
+		if !ok {
+			continue
+		}
+		indices[index] = true
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+
+	for i := 0; i <= maxIndex; i++ {
+		if !indices[i] {
+			return 0, fmt.Errorf("%s: missing list entry %d, but found entries up to index %d", prefix.String(), i, maxIndex)
+		}
+	}
+	return maxIndex + 1, nil
+}
+
+// findKeyPrefix returns the longest of keyPrefixes that key starts with.
+func findKeyPrefix(key string, keyPrefixes []string) (string, bool) {
+	var match string
+	found := false
+	for _, keyPrefix := range keyPrefixes {
+		if strings.HasPrefix(key, keyPrefix) && len(keyPrefix) >= len(match) {
+			match, found = keyPrefix, true
+		}
+	}
+	return match, found
 }
 
-func stringFromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
-	return assignFromEnvOrDefault(prefix, dst.SetString, tag)
+// checkUnknownEnvironment returns an error listing all environment variables with the given prefix that have not been read.
+func checkUnknownEnvironment(ctx *loadContext, prefix pathPrefix) error {
+	keyPrefix := ctx.EnvName(prefix)
+	if len(keyPrefix) == 0 {
+		// every variable would be unknown without prefix
+		return nil
+	}
+
+	knownKeys := make([]string, 0, len(ctx.EnvKeys))
+	for key := range ctx.EnvKeys {
+		knownKeys = append(knownKeys, key)
+	}
+	sort.Strings(knownKeys)
+
+	unknownKeys := make([]string, 0)
+	for _, entry := range environ() {
+		key := strings.SplitN(entry, "=", 2)[0]
+		if !strings.HasPrefix(key, keyPrefix) || (len(key) > len(keyPrefix) && isAlphanumeric(rune(key[len(keyPrefix)]))) {
+			// not followed by a separator
+			continue
+		}
+		if _, ok := ctx.EnvKeys[key]; ok {
+			continue
+		}
+
+		if suggestion, ok := closestMatch(key, knownKeys); ok {
+			unknownKeys = append(unknownKeys, fmt.Sprintf("%s (did you mean %s?)", key, suggestion))
+		} else {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		return fmt.Errorf("%s: unknown environment variables %s", prefix.String(), strings.Join(unknownKeys, ", "))
+	}
+	return nil
 }
 
-func boolFromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
-	return assignFromEnvOrDefault(prefix, dst.SetBoolFromString, tag)
+func isAlphanumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
 }
 
-func intFromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
-	return assignFromEnvOrDefault(prefix, dst.SetIntFromString, tag)
+// parseEnvIndex returns the index of a key segment written by the naming strategy.
+func parseEnvIndex(str string) (int, bool) {
+	if len(str) == 0 || (len(str) > 1 && str[0] == '0') {
+		return 0, false
+	}
+	for _, c := range str {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
 }
 
-func dateTimeFromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
-	return assignFromEnvOrDefault(prefix, dst.SetDateTimeFromString, tag)
+func arrayFromEnvironment(ctx *loadContext, prefix pathPrefix, dst *object) error {
+	return dst.IterateArray(func(i int, dst *object) error {
+		return fromEnvironment(ctx, prefix.Index(i), dst, nil)
+	})
 }
 
-func durationFromEnvironment(prefix pathPrefix, dst *object, tag *tag) error {
-	return assignFromEnvOrDefault(prefix, dst.SetDurationFromString, tag)
+func assignFromEnvOrDefault(ctx *loadContext, prefix pathPrefix, assignHandler func(string) error, tag *tag) error {
+	strVal, ok, err := fromEnvOrDefault(ctx, prefix, tag)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return assignString(ctx, prefix, assignHandler, strVal)
+	}
+	return nil
 }
 
-func assignFromEnvOrDefault(prefix pathPrefix, assignHandler func(string) error, tag *tag) error {
-	if strVal, ok := fromEnvOrDefault(prefix.Env(), tag); ok {
-		if err := assignHandler(strVal); err != nil {
-			return fmt.Errorf("%s: %s", prefix.String(), err.Error())
-		}
+// assignString expands variable references in strVal and passes it to assignHandler.
+func assignString(ctx *loadContext, prefix pathPrefix, assignHandler func(string) error, strVal string) error {
+	ctx.AssignCount++
+	strVal, err := ctx.Expand(strVal)
+	if err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	if err := assignHandler(strVal); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
 	}
+	ctx.Resolve(prefix, strVal)
 	return nil
 }
 
-func fromEnvOrDefault(key string, tag *tag) (string, bool) {
+func fromEnvOrDefault(ctx *loadContext, prefix pathPrefix, tag *tag) (string, bool, error) {
 	// explicit configuration from environment has highest priority
-	if strVal, ok := lookupEnv(key); ok {
-		return strVal, true
+	if strVal, ok, err := lookupEnvVariants(ctx, prefix); ok || err != nil {
+		return strVal, ok, err
 	}
 	// no env available? try default value
-	if tag.HasDefault {
-		return tag.Default, true
+	if strVal, ok := ctx.Default(tag); ok {
+		return strVal, true, nil
 	}
 	// is not configured at all
-	return "", false
+	return "", false, nil
+}
+
+// lookupEnvVariants returns the value of the environment variable for prefix or any of its aliases.
+func lookupEnvVariants(ctx *loadContext, prefix pathPrefix) (string, bool, error) {
+	var strVal, foundKey string
+	found := false
+	for _, variant := range prefix.Variants() {
+		key := ctx.EnvName(variant.Path)
+		val, ok := ctx.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		if variant.Deprecated {
+			ctx.Warn("environment variable %s is deprecated, use %s instead", key, ctx.EnvName(prefix))
+		}
+		if !found {
+			strVal, foundKey, found = val, key, true
+		} else if val != strVal {
+			return "", false, fmt.Errorf("%s: conflicting values in %s and %s", prefix.String(), foundKey, key)
+		}
+	}
+	return strVal, found, nil
 }
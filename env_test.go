@@ -1,6 +1,9 @@
 package config
 
 import (
+	"net"
+	"net/netip"
+	"net/url"
 	"testing"
 	"time"
 
@@ -174,6 +177,174 @@ func TestEnvPointer(t *testing.T) {
 	})
 }
 
+func TestEnvPointerUnset(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf EnvTestPointer
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Nil(t, conf.PtrString)
+			assert.Nil(t, conf.PtrValue)
+		}
+	})
+}
+
+func TestEnvPointerPartial(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_PTRVALUE_INTDATA"] = "42"
+
+		var conf EnvTestPointer
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Nil(t, conf.PtrString)
+			assert.Equal(t, &EnvTestSimple{IntData: 42}, conf.PtrValue)
+		}
+	})
+}
+
+type EnvTestPointerDefault struct {
+	Enabled *EnvTestDefault
+}
+
+func TestEnvPointerDefault(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf EnvTestPointerDefault
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, &EnvTestDefault{"foobar", 42, true, false}, conf.Enabled)
+		}
+	})
+}
+
+type EnvTestCommon struct {
+	LogLevel string
+}
+
+type EnvTestEmbedded struct {
+	EnvTestCommon
+	Port     int
+	Inline   EnvTestSimple `config:"inline"`
+	NoInline EnvTestCommon `config:"noinline"`
+}
+
+type EnvTestEmbeddedNoInline struct {
+	EnvTestCommon `config:"noinline,env:COMMON"`
+}
+
+func TestEnvEmbedded(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LOGLEVEL"] = "debug"
+		env["TEST_PORT"] = "8080"
+		env["TEST_STRINGDATA"] = "foobar"
+		env["TEST_NOINLINE_LOGLEVEL"] = "info"
+
+		var conf EnvTestEmbedded
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, "debug", conf.LogLevel)
+			assert.Equal(t, 8080, conf.Port)
+			assert.Equal(t, "foobar", conf.Inline.StringData)
+			assert.Equal(t, "info", conf.NoInline.LogLevel)
+		}
+
+		env["TEST_COMMON_LOGLEVEL"] = "warn"
+		var conf2 EnvTestEmbeddedNoInline
+		if assert.NoError(t, FromEnvironment("test", &conf2)) {
+			assert.Equal(t, "warn", conf2.LogLevel)
+		}
+	})
+}
+
+type EnvTestEmbeddedPtr struct {
+	*EnvTestCommon
+	Port int
+}
+
+func TestEnvEmbeddedPtr(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_PORT"] = "8080"
+
+		var conf EnvTestEmbeddedPtr
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Nil(t, conf.EnvTestCommon)
+			assert.Equal(t, 8080, conf.Port)
+		}
+
+		env["TEST_LOGLEVEL"] = "debug"
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, &EnvTestCommon{LogLevel: "debug"}, conf.EnvTestCommon)
+		}
+	})
+}
+
+type EnvTestEmbeddedValue struct {
+	url.URL
+	HostPort
+}
+
+func TestEnvEmbeddedValue(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_URL"] = "https://example.com/path"
+		env["TEST_HOSTPORT"] = "localhost:8080"
+
+		var conf EnvTestEmbeddedValue
+		if assert.NoError(t, FromEnvironment("test", &conf, WithStrictMode())) {
+			assert.Equal(t, "https://example.com/path", conf.URL.String())
+			assert.Equal(t, HostPort{"localhost", 8080}, conf.HostPort)
+		}
+
+		var conf2 EnvTestEmbeddedValue
+		if assert.NoError(t, FromJSON([]byte(`{"URL":"https://example.com","HostPort":":443"}`), &conf2, WithStrictMode())) {
+			assert.Equal(t, "example.com", conf2.URL.Host)
+			assert.Equal(t, HostPort{"", 443}, conf2.HostPort)
+		}
+	})
+}
+
+type EnvTestAlias struct {
+	Address string `config:"alias:HOST|SERVER,deprecated:ADDR"`
+	DB      struct {
+		User string
+	} `config:"alias:DATABASE"`
+}
+
+func TestEnvAlias(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_SERVER"] = "localhost"
+		env["TEST_DATABASE_USER"] = "jon"
+
+		var warnings []string
+		var conf EnvTestAlias
+		if assert.NoError(t, FromEnvironment("test", &conf, WithWarningHandler(func(msg string) { warnings = append(warnings, msg) }))) {
+			assert.Equal(t, "localhost", conf.Address)
+			assert.Equal(t, "jon", conf.DB.User)
+			assert.Empty(t, warnings)
+		}
+	})
+}
+
+func TestEnvDeprecated(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_ADDR"] = "localhost"
+		env["TEST_ADDRESS"] = "localhost"
+
+		var warnings []string
+		var conf EnvTestAlias
+		if assert.NoError(t, FromEnvironment("test", &conf, WithStrictMode(), WithWarningHandler(func(msg string) { warnings = append(warnings, msg) }))) {
+			assert.Equal(t, "localhost", conf.Address)
+			assert.Equal(t, []string{"environment variable TEST_ADDR is deprecated, use TEST_ADDRESS instead"}, warnings)
+		}
+	})
+}
+
+func TestEnvAliasConflict(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_ADDRESS"] = "localhost"
+		env["TEST_ADDR"] = "example.com"
+
+		var conf EnvTestAlias
+		err := FromEnvironment("test", &conf, WithWarningHandler(func(string) {}))
+		if assert.Error(t, err) {
+			assert.Equal(t, "test.Address: conflicting values in TEST_ADDRESS and TEST_ADDR", err.Error())
+		}
+	})
+}
+
 type EnvTestName struct {
 	StringData string        `config:"env:str"`
 	IntData    int           `config:"env:number"`
@@ -229,6 +400,132 @@ func TestEnvSlice(t *testing.T) {
 	})
 }
 
+func TestEnvSliceWithoutNum(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LIST_0"] = "foo"
+		env["TEST_LIST_1"] = "bar"
+
+		var conf EnvTestSlice
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Nil(t, conf.List)
+		}
+	})
+}
+
+func TestEnvSliceAutoLength(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LIST_0"] = "foo"
+		env["TEST_LIST_1"] = "bar"
+		env["TEST_LIST_2"] = "42"
+		env["TEST_NESTEDLIST_0_STRINGDATA"] = "foobar"
+		env["TEST_NESTEDLIST_1_INTDATA"] = "42"
+
+		var conf EnvTestSlice
+		if assert.NoError(t, FromEnvironment("test", &conf, WithAutoSliceLength())) {
+			assert.Nil(t, conf.EmptyList)
+			assert.Equal(t, []string{"foo", "bar", "42"}, conf.List)
+			assert.Equal(t, []EnvTestSimple{{StringData: "foobar"}, {IntData: 42}}, conf.NestedList)
+		}
+	})
+}
+
+func TestEnvSliceAutoLengthMatchingNum(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LIST_NUM"] = "2"
+		env["TEST_LIST_0"] = "foo"
+		env["TEST_LIST_1"] = "bar"
+
+		var conf EnvTestSlice
+		if assert.NoError(t, FromEnvironment("test", &conf, WithAutoSliceLength())) {
+			assert.Equal(t, []string{"foo", "bar"}, conf.List)
+		}
+	})
+}
+
+type EnvTestSliceAlias struct {
+	List []string `config:"alias:OLDLIST"`
+}
+
+func TestEnvSliceAutoLengthAlias(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_OLDLIST_NUM"] = "2"
+		env["TEST_OLDLIST_0"] = "foo"
+		env["TEST_OLDLIST_1"] = "bar"
+
+		var conf EnvTestSliceAlias
+		if assert.NoError(t, FromEnvironment("test", &conf, WithAutoSliceLength())) {
+			assert.Equal(t, []string{"foo", "bar"}, conf.List)
+		}
+
+		delete(env, "TEST_OLDLIST_NUM")
+		var conf2 EnvTestSliceAlias
+		if assert.NoError(t, FromEnvironment("test", &conf2, WithAutoSliceLength())) {
+			assert.Equal(t, []string{"foo", "bar"}, conf2.List)
+		}
+	})
+}
+
+func TestEnvSliceAutoLengthGap(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LIST_0"] = "foo"
+		env["TEST_LIST_2"] = "42"
+
+		var conf EnvTestSlice
+		err := FromEnvironment("test", &conf, WithAutoSliceLength())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "missing list entry 1")
+		}
+	})
+}
+
+func TestEnvSliceAutoLengthNumMismatch(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LIST_NUM"] = "3"
+		env["TEST_LIST_0"] = "foo"
+		env["TEST_LIST_1"] = "bar"
+
+		var conf EnvTestSlice
+		assert.Error(t, FromEnvironment("test", &conf, WithAutoSliceLength()))
+	})
+}
+
+type EnvTestStrict struct {
+	Name string
+	DB   struct {
+		Address string
+	}
+	List []int
+}
+
+func TestEnvStrict(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_NAME"] = "foobar"
+		env["TEST_DB_ADDRESS"] = "localhost"
+		env["TEST_LIST_NUM"] = "1"
+		env["TEST_LIST_0"] = "42"
+		env["OTHER_VALUE"] = "not in prefix"
+
+		var conf EnvTestStrict
+		assert.NoError(t, FromEnvironment("test", &conf, WithStrictMode()))
+	})
+}
+
+func TestEnvStrictUnknown(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_NAME"] = "foobar"
+		env["TEST_DB_ADRESS"] = "localhost"
+		env["TEST_SOMETHING"] = "else"
+
+		var conf EnvTestStrict
+		assert.NoError(t, FromEnvironment("test", &conf))
+
+		err := FromEnvironment("test", &conf, WithStrictMode())
+		if assert.Error(t, err) {
+			assert.Equal(t, "test: unknown environment variables TEST_DB_ADRESS (did you mean TEST_DB_ADDRESS?), TEST_SOMETHING", err.Error())
+		}
+	})
+}
+
 type EnvTestArray struct {
 	List [3]string
 }
@@ -279,6 +576,200 @@ func TestEnvDateTime(t *testing.T) {
 	})
 }
 
+type EnvTestDateTimeFormat struct {
+	Layout  time.Time `config:"layout:02.01.2006 15:04"`
+	Unix    time.Time `config:"layout:unix"`
+	Named   time.Time `config:"layout:RFC1123Z"`
+	Zoned   time.Time `config:"tz:UTC,default:2020-02-17 09:06:21"`
+	Invalid time.Time `config:"tz:Mars/Olympus"`
+}
+
+func TestEnvDateTimeFormat(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LAYOUT"] = "17.02.2020 09:06"
+		env["TEST_UNIX"] = "1581930381"
+		env["TEST_NAMED"] = "Mon, 17 Feb 2020 09:06:21 +0100"
+
+		var conf EnvTestDateTimeFormat
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 0, 0, time.Local), conf.Layout)
+			assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 0, time.UTC), conf.Unix.UTC())
+			assert.Equal(t, time.Date(2020, time.February, 17, 8, 6, 21, 0, time.UTC), conf.Named.UTC())
+			assert.Equal(t, time.Date(2020, time.February, 17, 9, 6, 21, 0, time.UTC), conf.Zoned)
+		}
+
+		env["TEST_INVALID"] = "2020-02-17"
+		assert.EqualError(t, FromEnvironment("test", &conf), `test.Invalid: unknown time zone "Mars/Olympus"`)
+	})
+}
+
+type EnvTestBytes struct {
+	Base64  []byte
+	Hex     []byte `config:"encoding:hex"`
+	Raw     []byte `config:"encoding:raw,default:foo"`
+	Pointer *[]byte
+	Unset   []byte
+}
+
+func TestEnvBytes(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_BASE64"] = "AQID/w=="
+		env["TEST_HEX"] = "010203ff"
+		env["TEST_POINTER"] = "AQID_w"
+
+		var conf EnvTestBytes
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, []byte{1, 2, 3, 255}, conf.Base64)
+			assert.Equal(t, []byte{1, 2, 3, 255}, conf.Hex)
+			assert.Equal(t, []byte("foo"), conf.Raw)
+			if assert.NotNil(t, conf.Pointer) {
+				assert.Equal(t, []byte{1, 2, 3, 255}, *conf.Pointer)
+			}
+			assert.Nil(t, conf.Unset)
+		}
+
+		env["TEST_HEX"] = "AQID/w=="
+		assert.EqualError(t, FromEnvironment("test", &conf), "test.Hex: cannot decode hex string: encoding/hex: invalid byte: U+0051 'Q'")
+	})
+}
+
+type EnvTestNetwork struct {
+	URL     url.URL
+	URLPtr  *url.URL
+	IP      net.IP `config:"default:127.0.0.1"`
+	Network *net.IPNet
+	MAC     net.HardwareAddr
+	Listen  netip.AddrPort
+	Server  HostPort `config:"default::8080"`
+}
+
+func TestEnvNetwork(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_URL"] = "https://example.com/api"
+		env["TEST_URLPTR"] = "postgres://user:secret@db:5432/app"
+		env["TEST_NETWORK"] = "192.168.0.0/16"
+		env["TEST_MAC"] = "00:00:5e:00:53:01"
+		env["TEST_LISTEN"] = "0.0.0.0:8080"
+
+		var conf EnvTestNetwork
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, "https://example.com/api", conf.URL.String())
+			if assert.NotNil(t, conf.URLPtr) {
+				assert.Equal(t, "db:5432", conf.URLPtr.Host)
+			}
+			assert.True(t, net.IPv4(127, 0, 0, 1).Equal(conf.IP))
+			if assert.NotNil(t, conf.Network) {
+				assert.True(t, conf.Network.Contains(net.IPv4(192, 168, 1, 1)))
+			}
+			assert.Equal(t, "00:00:5e:00:53:01", conf.MAC.String())
+			assert.Equal(t, uint16(8080), conf.Listen.Port())
+			assert.Equal(t, HostPort{"", 8080}, conf.Server)
+		}
+
+		env["TEST_SERVER"] = "localhost:9090"
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, HostPort{"localhost", 9090}, conf.Server)
+		}
+
+		env["TEST_LISTEN"] = "localhost"
+		assert.EqualError(t, FromEnvironment("test", &conf), `test.Listen: cannot parse ip address and port from "localhost"`)
+	})
+}
+
+type EnvTestEnum struct {
+	Level   EnumTestLevel
+	Default EnumTestLevel `config:"default:Error"`
+	Levels  []EnumTestLevel
+}
+
+func TestEnvEnum(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_LEVEL"] = "Warning"
+		env["TEST_LEVELS_NUM"] = "2"
+		env["TEST_LEVELS_0"] = "debug"
+		env["TEST_LEVELS_1"] = "INFO"
+
+		var conf EnvTestEnum
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, EnumTestWarn, conf.Level)
+			assert.Equal(t, EnumTestError, conf.Default)
+			assert.Equal(t, []EnumTestLevel{EnumTestDebug, EnumTestInfo}, conf.Levels)
+		}
+
+		env["TEST_LEVEL"] = "2"
+		assert.EqualError(t, FromEnvironment("test", &conf), `test.Level: unknown value "2", expected one of debug, info, warn, warning, error`)
+	})
+}
+
+type EnvTestInterpolation struct {
+	Host    string
+	URL     string `config:"default:postgres://${test.Host}:${TEST_PORT:-5432}/db"`
+	DataDir string
+	Escaped string
+}
+
+func TestEnvInterpolation(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["HOME"] = "/home/user"
+		env["TEST_HOST"] = "db"
+		env["TEST_DATADIR"] = "${HOME}/data"
+		env["TEST_ESCAPED"] = "$${HOME}"
+
+		var conf EnvTestInterpolation
+		if assert.NoError(t, FromEnvironment("test", &conf, WithStrictMode())) {
+			assert.Equal(t, "postgres://db:5432/db", conf.URL)
+			assert.Equal(t, "/home/user/data", conf.DataDir)
+			assert.Equal(t, "${HOME}", conf.Escaped)
+		}
+
+		env["TEST_DATADIR"] = "${TEST_ESCAPED}/${TEST_DATADIR}"
+		assert.EqualError(t, FromEnvironment("test", &conf), "test.DataDir: cyclic variable reference TEST_DATADIR -> TEST_DATADIR")
+	})
+}
+
+type EnvTestInterpolationPath struct {
+	DataDir string
+	Cache   string `config:"default:${Main.DataDir}/cache"`
+}
+
+func TestEnvInterpolationPath(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["MAIN_DATADIR"] = "/data"
+
+		var conf EnvTestInterpolationPath
+		if assert.NoError(t, FromEnvironment("MAIN", &conf)) {
+			assert.Equal(t, "/data/cache", conf.Cache)
+		}
+	})
+}
+
+type EnvTestProfile struct {
+	LogLevel string `config:"default:debug,default.prod:warn,default.staging:info"`
+	Workers  int    `config:"default.prod:8"`
+}
+
+func TestEnvProfile(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf EnvTestProfile
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, "debug", conf.LogLevel)
+			assert.Equal(t, 0, conf.Workers)
+		}
+
+		env["TEST_PROFILE"] = "prod"
+		conf = EnvTestProfile{}
+		if assert.NoError(t, FromEnvironment("test", &conf, WithProfileEnv("TEST_PROFILE"), WithStrictMode())) {
+			assert.Equal(t, "warn", conf.LogLevel)
+			assert.Equal(t, 8, conf.Workers)
+		}
+
+		conf = EnvTestProfile{}
+		if assert.NoError(t, FromEnvironment("test", &conf, WithProfileEnv("TEST_PROFILE"), WithProfile("staging"))) {
+			assert.Equal(t, "info", conf.LogLevel)
+		}
+	})
+}
+
 type EnvTestDuration struct {
 	Val           time.Duration
 	Default       time.Duration `config:"default:P1Y2M3DT4H5M6S"`
@@ -318,15 +809,41 @@ func TestEnvDuration(t *testing.T) {
 	})
 }
 
+type EnvTestDurationCalendar struct {
+	Fixed    time.Duration `config:"default:P1Y"`
+	Calendar time.Duration `config:"calendar,default:P1Y"`
+}
+
+func TestEnvDurationCalendar(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf EnvTestDurationCalendar
+		if assert.NoError(t, FromEnvironment("test", &conf)) {
+			assert.Equal(t, 365*24*time.Hour, conf.Fixed)
+			assert.Equal(t, 365*24*time.Hour+5*time.Hour+49*time.Minute+12*time.Second, conf.Calendar)
+		}
+	})
+}
+
 func withMockEnv(f func(map[string]string)) {
 	oldLookupEnv := lookupEnv
-	defer func() { lookupEnv = oldLookupEnv }()
+	oldEnviron := environ
+	defer func() {
+		lookupEnv = oldLookupEnv
+		environ = oldEnviron
+	}()
 
 	env := make(map[string]string)
 	lookupEnv = func(str string) (string, bool) {
 		val, ok := env[str]
 		return val, ok
 	}
+	environ = func() []string {
+		entries := make([]string, 0, len(env))
+		for key, val := range env {
+			entries = append(entries, key+"="+val)
+		}
+		return entries
+	}
 
 	f(env)
 }
@@ -0,0 +1,53 @@
+package config
+
+import "fmt"
+
+// Validator is implemented by configuration structs that check rules across multiple fields.
+//
+// Validate is called after loading and after all nested values have been validated.
+type Validator interface {
+	Validate() error
+}
+
+// Defaulter is implemented by configuration structs that initialize their own default values.
+//
+// SetDefaults is called before the struct is filled by a loader, so loaded values take precedence. It is only called for zero values, so values of a previous loader are kept when loaders are chained.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// AfterLoader is implemented by configuration structs that need to post-process loaded values.
+//
+// AfterLoad is called after all fields of the struct have been filled by a loader.
+type AfterLoader interface {
+	AfterLoad() error
+}
+
+func callSetDefaults(obj *object) {
+	if !obj.v.IsZero() {
+		// do not reset values loaded before
+		return
+	}
+	if defaulter, ok := obj.Implementor().(Defaulter); ok {
+		defaulter.SetDefaults()
+	}
+}
+
+func callAfterLoad(prefix pathPrefix, obj *object) error {
+	if afterLoader, ok := obj.Implementor().(AfterLoader); ok {
+		if err := afterLoader.AfterLoad(); err != nil {
+			if len(prefix) == 0 {
+				return err
+			}
+			return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+		}
+	}
+	return nil
+}
+
+func callValidate(obj *object) error {
+	if validator, ok := obj.Implementor().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
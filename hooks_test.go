@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type HooksTestTLS struct {
+	Cert string
+	Key  string
+}
+
+func (c *HooksTestTLS) Validate() error {
+	if (len(c.Cert) > 0) != (len(c.Key) > 0) {
+		return fmt.Errorf("cert and key must both be set")
+	}
+	return nil
+}
+
+type HooksTestServer struct {
+	Host    string
+	Port    int
+	Address string
+	TLS     HooksTestTLS
+}
+
+func (c *HooksTestServer) SetDefaults() {
+	c.Host = "localhost"
+	c.Port = 8080
+}
+
+func (c *HooksTestServer) AfterLoad() error {
+	if strings.Contains(c.Host, ":") {
+		return fmt.Errorf("host must not contain a port")
+	}
+	c.Address = fmt.Sprintf("%s:%d", c.Host, c.Port)
+	return nil
+}
+
+type HooksTestConfig struct {
+	Server HooksTestServer
+}
+
+func TestHooksFromEnvironment(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_SERVER_PORT"] = "443"
+
+		var conf HooksTestConfig
+		require.NoError(t, FromEnvironment("test", &conf))
+		assert.Equal(t, "localhost", conf.Server.Host)
+		assert.Equal(t, 443, conf.Server.Port)
+		assert.Equal(t, "localhost:443", conf.Server.Address)
+	})
+}
+
+func TestHooksSetDefaultsChained(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf HooksTestConfig
+		require.NoError(t, FromJSON([]byte(`{"Server":{"Host":"example.com"}}`), &conf))
+		assert.Equal(t, "example.com", conf.Server.Host)
+		assert.Equal(t, 8080, conf.Server.Port)
+
+		env["TEST_SERVER_PORT"] = "443"
+		require.NoError(t, FromEnvironment("test", &conf))
+		assert.Equal(t, "example.com", conf.Server.Host)
+		assert.Equal(t, 443, conf.Server.Port)
+		assert.Equal(t, "example.com:443", conf.Server.Address)
+	})
+}
+
+type HooksTestNested struct {
+	X      int
+	Server HooksTestServer
+}
+
+func TestHooksFromJSONMissingKey(t *testing.T) {
+	var conf HooksTestNested
+	require.NoError(t, FromJSON([]byte(`{"X":1}`), &conf))
+	assert.Equal(t, HooksTestServer{Host: "localhost", Port: 8080, Address: "localhost:8080"}, conf.Server)
+
+	conf = HooksTestNested{}
+	require.NoError(t, loadJSONTree([]byte(`{"X":1}`), &conf))
+	assert.Equal(t, HooksTestServer{Host: "localhost", Port: 8080, Address: "localhost:8080"}, conf.Server)
+
+	// patches only assign the given values
+	conf = HooksTestNested{}
+	require.NoError(t, FromJSONPatch(&conf, []byte(`{"X":1}`)))
+	assert.Equal(t, HooksTestServer{}, conf.Server)
+}
+
+func TestHooksAfterLoadError(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_SERVER_HOST"] = "localhost:80"
+
+		var conf HooksTestConfig
+		err := FromEnvironment("test", &conf)
+		if assert.Error(t, err) {
+			assert.Equal(t, "test.Server: host must not contain a port", err.Error())
+		}
+	})
+}
+
+func TestHooksValidate(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_SERVER_TLS_CERT"] = "cert.pem"
+
+		var conf HooksTestConfig
+		err := FromEnvironment("test", &conf)
+		if assert.Error(t, err) {
+			assert.Equal(t, "test.Server.TLS: cert and key must both be set", err.Error())
+		}
+	})
+}
+
+func TestHooksFromJSON(t *testing.T) {
+	var conf HooksTestConfig
+	require.NoError(t, FromJSON([]byte(`{"Server":{"Port":443}}`), &conf))
+	assert.Equal(t, "localhost:443", conf.Server.Address)
+
+	err := FromJSON([]byte(`{"Server":{"TLS":{"Key":"key.pem"}}}`), &conf)
+	if assert.Error(t, err) {
+		assert.Equal(t, "Server.TLS: cert and key must both be set", err.Error())
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const includeKey = "$include"
+
+var (
+	// configFileExtensions contains the file extensions loaded by FromConfigDir.
+	configFileExtensions = []string{".json", ".jsonc", ".json5"}
+)
+
+// FromConfigDir loads all config files in dir, e.g. /etc/app/conf.d, in lexical order and merges them into the given configuration. Later files override values of earlier files.
+//
+// Errors are prefixed with the file, line and column of the invalid value. Missing or empty directories are rejected unless WithOptionalDir is set.
+func FromConfigDir(dir string, conf interface{}, opts ...Option) error {
+	ctx := newLoadContext(opts)
+	files, err := configDirFiles(dir)
+	if err != nil && !(os.IsNotExist(err) && ctx.Options.OptionalDir) {
+		return err
+	}
+	if len(files) == 0 && !ctx.Options.OptionalDir {
+		return fmt.Errorf("no config files found in %s", dir)
+	}
+
+	var obj interface{} = map[string]interface{}{}
+	for _, file := range files {
+		fragment, err := readJSONFile(ctx, file, nil)
+		if err != nil {
+			return err
+		}
+		obj = mergeJSON(obj, fragment, ctx.Options.SliceMerge)
+	}
+	return loadJSON(ctx, obj, conf)
+}
+
+// configDirFiles returns all files in dir with a supported extension in lexical order.
+func configDirFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		for _, ext := range configFileExtensions {
+			if strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+				files = append(files, filepath.Join(dir, entry.Name()))
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+// jsonDocument is a loaded JSON document, which is searched for the positions of invalid values.
+type jsonDocument struct {
+	// File is empty for documents that are not read from a file.
+	File    string
+	Data    []byte
+	Relaxed bool
+}
+
+// jsonSource is the origin of a JSON value.
+type jsonSource struct {
+	// File is empty for documents that are not read from a file.
+	File string
+	Pos  jsonPos
+}
+
+// sourceError denotes an error caused by a value at the given source position.
+type sourceError struct {
+	Source jsonSource
+	Err    error
+}
+
+func (e *sourceError) Error() string {
+	if len(e.Source.File) == 0 {
+		return fmt.Sprintf("%d:%d: %s", e.Source.Pos.Line, e.Source.Pos.Column, e.Err.Error())
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Source.File, e.Source.Pos.Line, e.Source.Pos.Column, e.Err.Error())
+}
+
+func (e *sourceError) Unwrap() error {
+	return e.Err
+}
+
+// SourceError annotates err with the file and position of the value at prefix, if known.
+func (ctx *loadContext) SourceError(prefix pathPrefix, err error) error {
+	if _, ok := err.(*sourceError); ok {
+		return err
+	}
+	// later documents override values of earlier ones
+	for i := len(ctx.Documents) - 1; i >= 0; i-- {
+		doc := &ctx.Documents[i]
+		if pos, ok := locateJSON(doc.Data, doc.Relaxed, prefix); ok {
+			return &sourceError{jsonSource{doc.File, pos}, err}
+		}
+	}
+	return err
+}
+
+// readJSONFile parses a JSON file and merges all files referenced by its top-level "$include" key below its own values.
+//
+// Included paths are relative to the including file and may contain glob patterns. All files are recorded in ctx.Documents.
+func readJSONFile(ctx *loadContext, path string, stack []string) (interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("include cycle %s", strings.Join(append(stack[i:], absPath), " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := ctx.ParseJSON(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, ok := obj.(map[string]interface{})
+	if !ok || doc[includeKey] == nil {
+		ctx.RecordDocument(path, data)
+		return obj, nil
+	}
+
+	patterns, err := includePatterns(doc[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s: %s", path, includeKey, err.Error())
+	}
+	delete(doc, includeKey)
+
+	var merged interface{}
+	for _, pattern := range patterns {
+		files, err := resolveInclude(filepath.Join(filepath.Dir(path), filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%s: %s", path, includeKey, err.Error())
+		}
+		for _, file := range files {
+			included, err := readJSONFile(ctx, file, stack)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeJSON(merged, included, ctx.Options.SliceMerge)
+		}
+	}
+
+	ctx.RecordDocument(path, data)
+	return mergeJSON(merged, doc, ctx.Options.SliceMerge), nil
+}
+
+// includePatterns returns the value of an "$include" key, which is either a string or an array of strings.
+func includePatterns(obj interface{}) ([]string, error) {
+	switch val := obj.(type) {
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		patterns := make([]string, len(val))
+		for i, item := range val {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string or array of strings")
+			}
+			patterns[i] = pattern
+		}
+		return patterns, nil
+	default:
+		return nil, fmt.Errorf("expected string or array of strings")
+	}
+}
+
+// resolveInclude returns the files matching pattern in lexical order. Paths without glob characters must exist.
+func resolveInclude(pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, `*?[`) {
+		return []string{pattern}, nil
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return files, nil
+}
+
+// RecordDocument remembers the document data read from file as origin of values for error messages. Documents of later calls take precedence.
+func (ctx *loadContext) RecordDocument(file string, data []byte) {
+	ctx.Documents = append(ctx.Documents, jsonDocument{file, data, ctx.RelaxedJSON(file)})
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type IncludeTest struct {
+	Name string
+	DB   struct {
+		Host string
+		Port int
+	}
+	Features []string
+}
+
+func TestFromFileInclude(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"config.json":          `{"$include":["db.json","conf.d/*.json"],"Name":"main"}`,
+		"db.json":              `{"$include":"defaults/db.json","DB":{"Host":"db"}}`,
+		"defaults/db.json":     `{"DB":{"Host":"localhost","Port":5432},"Name":"default"}`,
+		"conf.d/20-late.json":  `{"Features":["b"]}`,
+		"conf.d/10-early.json": `{"Features":["a"],"Name":"early"}`,
+	}, func(dir string) {
+		var conf IncludeTest
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf)) {
+			assert.Equal(t, "main", conf.Name)
+			assert.Equal(t, "db", conf.DB.Host)
+			assert.Equal(t, 5432, conf.DB.Port)
+			assert.Equal(t, []string{"b"}, conf.Features)
+		}
+
+		conf = IncludeTest{}
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf, WithSliceMerge(SliceMergeAppend))) {
+			assert.Equal(t, []string{"a", "b"}, conf.Features)
+		}
+	})
+}
+
+func TestFromFileIncludeErrors(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"cycle.json":    `{"$include":"nested/a.json"}`,
+		"nested/a.json": `{"$include":"../cycle.json"}`,
+		"missing.json":  `{"$include":["missing/*.json","missing.json.d"]}`,
+		"invalid.json":  `{"$include":42}`,
+		"value.json":    `{"$include":"db.json","Name":"main"}`,
+		"db.json":       `{"DB":{"Host":42,"Port":5432}}`,
+	}, func(dir string) {
+		var conf IncludeTest
+		err := FromFile(filepath.Join(dir, "cycle.json"), &conf)
+		if assert.Error(t, err) {
+			assert.Equal(t, fmt.Sprintf("include cycle %s -> %s -> %s", filepath.Join(dir, "cycle.json"), filepath.Join(dir, "nested", "a.json"), filepath.Join(dir, "cycle.json")), err.Error())
+		}
+
+		err = FromFile(filepath.Join(dir, "missing.json"), &conf)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "missing.json.d")
+		}
+
+		err = FromFile(filepath.Join(dir, "invalid.json"), &conf)
+		assert.EqualError(t, err, filepath.Join(dir, "invalid.json")+":$include: expected string or array of strings")
+
+		err = FromFile(filepath.Join(dir, "value.json"), &conf)
+		assert.EqualError(t, err, filepath.Join(dir, "db.json")+":1:15: DB.Host: cannot parse string from type float64")
+	})
+}
+
+func TestFromConfigDir(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"conf.d/10-db.json":       `{"DB":{"Host":"localhost","Port":5432}}`,
+		"conf.d/20-override.json": `{"DB":{"Host":"db"},"Features":["a"]}`,
+		"conf.d/00-name.JSON":     `{"Name":"app"}`,
+		"conf.d/.hidden.json":     `{"Name":"hidden"}`,
+		"conf.d/README.md":        `not loaded`,
+		"conf.d/sub/99-sub.json":  `{"Name":"sub"}`,
+		"empty/README.md":         ``,
+		"invalid/10-ok.json":      `{"Name":"app"}`,
+		"invalid/20-bad.json":     `{"Features":"a"}`,
+	}, func(dir string) {
+		var conf IncludeTest
+		if assert.NoError(t, FromConfigDir(filepath.Join(dir, "conf.d"), &conf)) {
+			assert.Equal(t, "app", conf.Name)
+			assert.Equal(t, "db", conf.DB.Host)
+			assert.Equal(t, 5432, conf.DB.Port)
+			assert.Equal(t, []string{"a"}, conf.Features)
+		}
+
+		err := FromConfigDir(filepath.Join(dir, "invalid"), &conf)
+		assert.EqualError(t, err, filepath.Join(dir, "invalid", "20-bad.json")+":1:13: Features: cannot parse slice from type string")
+
+		assert.EqualError(t, FromConfigDir(filepath.Join(dir, "empty"), &conf), "no config files found in "+filepath.Join(dir, "empty"))
+		assert.Error(t, FromConfigDir(filepath.Join(dir, "missing"), &conf))
+
+		conf = IncludeTest{}
+		assert.NoError(t, FromConfigDir(filepath.Join(dir, "empty"), &conf, WithOptionalDir()))
+		assert.NoError(t, FromConfigDir(filepath.Join(dir, "missing"), &conf, WithOptionalDir()))
+		assert.Equal(t, IncludeTest{}, conf)
+	})
+}
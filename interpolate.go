@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Expand replaces all references ${NAME} in strVal by the value of the already loaded configuration path or environment variable NAME.
+//
+// References can specify a fallback value ${NAME:-fallback} that is used if NAME is undefined or empty. Use $${ for a literal ${.
+func (ctx *loadContext) Expand(strVal string) (string, error) {
+	return ctx.expand(strVal, nil)
+}
+
+func (ctx *loadContext) expand(strVal string, stack []string) (string, error) {
+	if !strings.Contains(strVal, "${") {
+		return strVal, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(strVal); {
+		if strings.HasPrefix(strVal[i:], "$${") {
+			sb.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(strVal[i:], "${") {
+			sb.WriteByte(strVal[i])
+			i++
+			continue
+		}
+
+		end := findReferenceEnd(strVal, i+2)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference at offset %d", i)
+		}
+		val, err := ctx.resolveReference(strVal[i+2:end], stack)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(val)
+		i = end + 1
+	}
+	return sb.String(), nil
+}
+
+// findReferenceEnd returns the index of the closing brace of a reference starting at offset, or -1 if it is not terminated.
+func findReferenceEnd(strVal string, offset int) int {
+	depth := 1
+	for i := offset; i < len(strVal); i++ {
+		switch {
+		case strings.HasPrefix(strVal[i:], "${"):
+			// nested references in fallback values
+			depth++
+			i++
+		case strVal[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (ctx *loadContext) resolveReference(reference string, stack []string) (string, error) {
+	name, fallback, hasFallback := reference, "", false
+	if pos := strings.Index(reference, ":-"); pos >= 0 {
+		name, fallback, hasFallback = reference[:pos], reference[pos+2:], true
+	}
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return "", fmt.Errorf("empty variable reference")
+	}
+
+	for i, n := range stack {
+		if n == name {
+			return "", fmt.Errorf("cyclic variable reference %s", strings.Join(append(stack[i:], name), " -> "))
+		}
+	}
+
+	// configuration values are already expanded
+	val, ok := ctx.Resolved[strings.ToLower(name)]
+	if !ok {
+		if val, ok = ctx.LookupEnv(name); ok {
+			var err error
+			if val, err = ctx.expand(val, append(stack, name)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if (!ok || len(val) == 0) && hasFallback {
+		return ctx.expand(fallback, stack)
+	}
+	if !ok {
+		return "", fmt.Errorf("undefined variable %q", name)
+	}
+	return val, nil
+}
+
+// Resolve remembers the loaded value of prefix to be referenced by other values if references are tracked. Paths are matched case-insensitive, as the env prefix is usually written in upper case.
+func (ctx *loadContext) Resolve(prefix pathPrefix, strVal string) {
+	if ctx.Resolved != nil {
+		ctx.Resolved[strings.ToLower(prefix.String())] = strVal
+	}
+}
+
+// ResolveValue remembers the formatted value of a number or boolean like Resolve.
+func (ctx *loadContext) ResolveValue(prefix pathPrefix, val interface{}) {
+	if ctx.Resolved != nil {
+		ctx.Resolve(prefix, fmt.Sprint(val))
+	}
+}
+
+// TrackReferences lets Resolve remember loaded values if any value may reference them. This is the case if a recorded document or a default value of type t contains a reference, while all values are tracked for environment variables.
+func (ctx *loadContext) TrackReferences(t reflect.Type) {
+	if ctx.Resolved != nil {
+		return
+	}
+	track := t != nil && getTypeInfo(t).References
+	for _, doc := range ctx.Documents {
+		track = track || mayContainReference(doc.Data)
+	}
+	if track {
+		ctx.Resolved = make(map[string]string)
+	}
+}
+
+// mayContainReference returns true if a string of the JSON document data may contain a variable reference, including references with escaped characters.
+func mayContainReference(data []byte) bool {
+	if bytes.Contains(data, []byte("${")) {
+		return true
+	}
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] != '\\' {
+			continue
+		}
+		switch data[i+1] {
+		case '"', '\'', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			// escapes that cannot produce "$" or "{"
+			i++
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["HOST"] = "localhost"
+		env["URL"] = "http://${HOST}:${PORT:-8080}"
+		env["EMPTY"] = ""
+
+		ctx := newLoadContext(nil)
+		ctx.Resolved = make(map[string]string)
+		ctx.Resolve(newPathPrefix("MAIN").Field("Name"), "app")
+
+		testCases := map[string]string{
+			"plain":                        "plain",
+			"${HOST}":                      "localhost",
+			"${URL}/api":                   "http://localhost:8080/api",
+			"/data/${Main.Name}":           "/data/app",
+			"${EMPTY:-fallback}":           "fallback",
+			"${MISSING:-${HOST}}":          "localhost",
+			"${MISSING:-}":                 "",
+			"$${HOST} and $$ and $ and {}": "${HOST} and $$ and $ and {}",
+		}
+		for str, expected := range testCases {
+			val, err := ctx.Expand(str)
+			if assert.NoError(t, err, str) {
+				assert.Equal(t, expected, val, str)
+			}
+		}
+	})
+}
+
+func TestExpandErrors(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["A"] = "${B}"
+		env["B"] = "x${A}"
+
+		ctx := newLoadContext(nil)
+		_, err := ctx.Expand("${A}")
+		assert.EqualError(t, err, "cyclic variable reference A -> B -> A")
+		_, err = ctx.Expand("${MISSING}")
+		assert.EqualError(t, err, `undefined variable "MISSING"`)
+		_, err = ctx.Expand("foo ${BAR")
+		assert.EqualError(t, err, "unterminated variable reference at offset 4")
+		_, err = ctx.Expand("${:-foo}")
+		assert.EqualError(t, err, "empty variable reference")
+	})
+}
@@ -1,42 +1,142 @@
 package config
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // FromFile reads a JSON file and updates the given configuration.
 //
-// Respects the default json tag values.
-func FromFile(path string, conf interface{}) error {
-	data, err := ioutil.ReadFile(path)
+// Respects the default json tag values. A top-level "$include" key with a path or list of paths, relative to the file and with optional glob patterns, merges the referenced files below the values of the file. If a profile is set, the overlay file with the profile name inserted before the extension, e.g. config.prod.json, is merged on top of the file if it exists. Files without includes and overlay are decoded in a single pass like by FromJSON.
+//
+// Errors are prefixed with the file, line and column of the invalid value.
+func FromFile(path string, conf interface{}, opts ...Option) error {
+	ctx := newLoadContext(opts)
+	overlayPath := ""
+	if len(ctx.Options.Profile) > 0 {
+		if _, err := os.Stat(profilePath(path, ctx.Options.Profile)); err == nil {
+			overlayPath = profilePath(path, ctx.Options.Profile)
+		}
+	}
+
+	if len(overlayPath) == 0 {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(data, []byte(includeKey)) {
+			// single files are decoded directly into conf without building a tree of maps
+			return decodeJSON(ctx, path, data, conf)
+		}
+	}
+
+	obj, err := readJSONFile(ctx, path, nil)
 	if err != nil {
 		return err
 	}
-
-	return FromJSON(data, conf)
+	if len(overlayPath) > 0 {
+		overlay, err := readJSONFile(ctx, overlayPath, nil)
+		if err != nil {
+			return err
+		}
+		obj = mergeJSON(obj, overlay, ctx.Options.SliceMerge)
+	}
+	return loadJSON(ctx, obj, conf)
 }
 
 // FromJSON parses JSON data and updates the given configuration.
 //
-// Respects the default json tag values.
-func FromJSON(data []byte, conf interface{}) error {
-	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
+// Respects the default json tag values. The document is decoded in a single pass directly into conf. Errors are prefixed with the line and column of the invalid value.
+func FromJSON(data []byte, conf interface{}, opts ...Option) error {
+	return decodeJSON(newLoadContext(opts), "", data, conf)
+}
+
+// FromJSONPatch applies a JSON Merge Patch according to RFC 7386 to the given configuration, e.g. to override values at runtime.
+//
+// Keys missing in the patch are left untouched and null resets values to their zero value. The configuration is only modified if the patch can be applied completely and the result is valid. Synchronize access to conf if it is used concurrently.
+func FromJSONPatch(conf interface{}, patch []byte, opts ...Option) error {
+	ctx := newLoadContext(opts)
+	obj, err := ctx.ParseJSON(patch, "")
+	if err != nil {
+		return err
+	}
+	ctx.RecordDocument("", patch)
+
+	dst := newObject(conf)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return fmt.Errorf("conf must be a non-nil pointer")
+	}
+
+	// patch a copy to leave conf untouched on errors
+	patched := &object{dst.t, reflect.New(dst.t.Elem())}
+	patched.v.Elem().Set(deepCopy(dst.v.Elem()))
+
+	ctx.Patch = true
+	if err := loadJSON(ctx, obj, patched.Interface()); err != nil {
 		return err
 	}
+	dst.v.Elem().Set(patched.v.Elem())
+	return nil
+}
+
+// profilePath returns the path of the overlay file for profile, e.g. config.prod.json for config.json.
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// ParseJSON decodes a JSON document. The source file path may be empty.
+func (ctx *loadContext) ParseJSON(data []byte, path string) (interface{}, error) {
+	obj, err := parseJSON(data, ctx.RelaxedJSON(path))
+	if err != nil {
+		if len(path) > 0 {
+			return nil, fmt.Errorf("%s:%s", path, err.Error())
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// RelaxedJSON returns true if the document at path is parsed in relaxed JSON5 mode, because WithRelaxedJSON is set or path has the extension .jsonc or .json5.
+func (ctx *loadContext) RelaxedJSON(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ctx.Options.RelaxedJSON || ext == ".jsonc" || ext == ".json5"
+}
 
+// loadJSON assigns the decoded JSON document obj to conf and validates the result.
+func loadJSON(ctx *loadContext, obj interface{}, conf interface{}) error {
 	dst := newObject(conf)
 	if !dst.IsAssignable() {
 		return fmt.Errorf("conf must be an assignable value")
 	}
-	return fromJSON(obj, newPathPrefix(""), dst, nil)
+
+	ctx.TrackReferences(dst.t)
+	rootPrefix := newPathPrefix("")
+	if err := fromJSON(ctx, obj, rootPrefix, dst, nil); err != nil {
+		return err
+	}
+	if ctx.Options.NoValidation {
+		return nil
+	}
+	return validate(rootPrefix, dst, nil)
+}
+
+func fromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	if err := fromJSONValue(ctx, obj, prefix, dst, tag); err != nil {
+		return ctx.SourceError(prefix, err)
+	}
+	return nil
 }
 
-func fromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+func fromJSONValue(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
 	if obj == nil {
 		if !dst.v.CanAddr() {
 			return fmt.Errorf("%s: cannot assign null to type %T", prefix.String(), dst.Interface())
@@ -47,31 +147,29 @@ func fromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
 
 	//TODO custom types with interfaces
 
-	if dst.Is(typeDateTime) {
-		return dateTimeFromJSON(obj, prefix, dst, tag)
-	}
-	if dst.Is(typeDuration) {
-		return durationFromJSON(obj, prefix, dst, tag)
+	if fromJSONFunc := leafFromJSONFunc(dst, tag); fromJSONFunc != nil {
+		return fromJSONFunc(ctx, obj, prefix, dst, tag)
 	}
 
 	switch dst.Kind() {
 	case reflect.Ptr:
-		return fromJSON(obj, prefix, dst.Elem(), tag)
+		// optional values are only allocated when present in JSON
+		return fromJSON(ctx, obj, prefix, dst.AllocElem(), tag)
 
 	case reflect.Struct:
-		return structFromJSON(obj, prefix, dst)
+		return structFromJSON(ctx, obj, prefix, dst)
 
 	case reflect.Slice:
-		return sliceFromJSON(obj, prefix, dst)
+		return sliceFromJSON(ctx, obj, prefix, dst)
 	case reflect.Array:
-		return arrayFromJSON(obj, prefix, dst)
+		return arrayFromJSON(ctx, obj, prefix, dst)
 
 	case reflect.String:
-		return stringFromJSON(obj, prefix, dst, tag)
+		return stringFromJSON(ctx, obj, prefix, dst, tag)
 	case reflect.Bool:
-		return boolFromJSON(obj, prefix, dst, tag)
+		return boolFromJSON(ctx, obj, prefix, dst, tag)
 	case reflect.Int:
-		return intFromJSON(obj, prefix, dst, tag)
+		return intFromJSON(ctx, obj, prefix, dst, tag)
 
 	default:
 		// just ignore unsupported types
@@ -79,47 +177,304 @@ func fromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
 	}
 }
 
-func structFromJSON(obj interface{}, prefix pathPrefix, dst *object) error {
+// leafFromJSONFunc returns the function to parse types that are represented by a single JSON value, but composite in Go, or nil for all other types.
+func leafFromJSONFunc(dst *object, tag *tag) func(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	switch {
+	case dst.Is(typeDateTime):
+		return dateTimeFromJSON
+	case dst.Is(typeDuration):
+		return durationFromJSON
+	case dst.Is(typeByteSize) || (tag != nil && tag.Unit == unitBytes && dst.Kind() == reflect.Int):
+		return byteSizeFromJSON
+	case isEnumType(dst.t):
+		return enumFromJSON
+	case isNetworkType(dst.t):
+		return networkFromJSON
+	case isByteSlice(dst.t):
+		return bytesFromJSON
+	default:
+		return nil
+	}
+}
+
+func structFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object) error {
 	t := reflect.TypeOf(obj)
 	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
 		return fmt.Errorf("%s: cannot parse struct from type %T", prefix.String(), obj)
 	}
 
 	// source obj must be a map
-	src := make(map[string]interface{})
-	v := reflect.ValueOf(obj)
-	for _, key := range v.MapKeys() {
-		src[key.Interface().(string)] = v.MapIndex(key).Interface()
-	}
-
-	//TODO use dst.IterateStruct
-	fieldCount := dst.t.NumField()
-	for i := 0; i < fieldCount; i++ {
-		field := dst.t.Field(i)
-		tag := getTag(field)
-		val := dst.v.Field(i)
-
-		fieldName := tag.FieldName
-		// field name can be overwritten by json tag
-		if jsonTag := field.Tag.Get("json"); len(jsonTag) > 0 {
-			parts := strings.Split(jsonTag, ",")
-			if len(parts) == 1 && parts[0] == "-" {
-				// do not allow json input for this field
-				continue
+	src, ok := obj.(map[string]interface{})
+	if !ok {
+		src = make(map[string]interface{})
+		v := reflect.ValueOf(obj)
+		for _, key := range v.MapKeys() {
+			src[key.Interface().(string)] = v.MapIndex(key).Interface()
+		}
+	}
+
+	s := newJSONStruct(ctx, prefix, dst, src)
+	for i := range s.typ.Fields {
+		tag := s.Tag(i)
+		obj, ok := src[tag.JSONName]
+		if !ok || len(tag.Aliases) > 0 {
+			continue
+		}
+		field := s.field(i)
+		if field == nil {
+			continue
+		}
+		s.Found[i] = true
+		if err := fromJSON(ctx, obj, prefix.Field(tag.JSONName), field, tag); err != nil {
+			return err
+		}
+	}
+	return s.Finish()
+}
+
+// jsonField is a field of a struct type that can be read from JSON.
+type jsonField struct {
+	// Index contains the field indices from the struct to the field, following inlined structs and pointers to them.
+	Index []int
+	Tag   *tag
+}
+
+// jsonStructType contains the JSON fields of a struct type in the order visited by IterateStruct.
+type jsonStructType struct {
+	Fields []jsonField
+	// Direct maps keys to the first field without aliases of that name. Values of all other keys are collected for Finish.
+	Direct map[string]int
+	// Aliases contains the names and aliases of fields with aliases.
+	Aliases map[string]bool
+}
+
+// jsonStructTypeCache maps struct types to their *jsonStructType.
+var jsonStructTypeCache sync.Map
+
+// getJSONStructType returns the JSON fields of the struct type t, which are only collected once per type.
+func getJSONStructType(t reflect.Type) *jsonStructType {
+	if st, ok := jsonStructTypeCache.Load(t); ok {
+		return st.(*jsonStructType)
+	}
+
+	st := &jsonStructType{Direct: make(map[string]int), Aliases: make(map[string]bool)}
+	st.addFields(t, nil)
+	jsonStructTypeCache.Store(t, st)
+	return st
+}
+
+func (st *jsonStructType) addFields(t reflect.Type, index []int) {
+	fields := structFields(t)
+	for i := range fields {
+		field := &fields[i]
+		fieldIndex := append(index[:len(index):len(index)], field.Index)
+		if field.Tag.Inline {
+			inlineType := field.Type
+			if inlineType.Kind() == reflect.Ptr {
+				inlineType = inlineType.Elem()
+			}
+			st.addFields(inlineType, fieldIndex)
+			continue
+		}
+		if field.Tag.NoJSON {
+			continue
+		}
+
+		if len(field.Tag.Aliases) == 0 {
+			if _, ok := st.Direct[field.Tag.JSONName]; !ok {
+				st.Direct[field.Tag.JSONName] = len(st.Fields)
 			}
-			fieldName = parts[0]
+		} else {
+			st.Aliases[field.Tag.JSONName] = true
+			for _, alias := range field.Tag.Aliases {
+				st.Aliases[alias.Name] = true
+			}
+		}
+		st.Fields = append(st.Fields, jsonField{fieldIndex, &field.Tag})
+	}
+}
+
+// jsonStruct assigns the members of a JSON object to a struct. Members are either assigned directly to the field returned by Field, or collected and assigned by Finish.
+type jsonStruct struct {
+	ctx    *loadContext
+	prefix pathPrefix
+	dst    *object
+	typ    *jsonStructType
+	// Found denotes the fields that have been assigned directly.
+	Found []bool
+	// src contains the collected values of fields with aliases and, in strict mode, of unknown keys.
+	src map[string]interface{}
+	// releaseInline resets pointers to inlined structs that are not present in the document.
+	releaseInline func()
+}
+
+func newJSONStruct(ctx *loadContext, prefix pathPrefix, dst *object, src map[string]interface{}) *jsonStruct {
+	if !ctx.Patch {
+		callSetDefaults(dst)
+	}
+
+	typ := getJSONStructType(dst.t)
+	return &jsonStruct{
+		ctx:           ctx,
+		prefix:        prefix,
+		dst:           dst,
+		typ:           typ,
+		Found:         make([]bool, len(typ.Fields)),
+		src:           src,
+		releaseInline: dst.AllocInline(),
+	}
+}
+
+// Field returns the index and value of the field that is assigned directly from key, or -1 and nil if the value must be collected.
+func (s *jsonStruct) Field(key string) (int, *object) {
+	i, ok := s.typ.Direct[key]
+	if !ok {
+		return -1, nil
+	}
+	field := s.field(i)
+	if field == nil {
+		return -1, nil
+	}
+	return i, field
+}
+
+// Tag returns the tag of field i.
+func (s *jsonStruct) Tag(i int) *tag {
+	return s.typ.Fields[i].Tag
+}
+
+// field returns the value of field i, or nil if it is not assignable or belongs to a nil inlined struct.
+func (s *jsonStruct) field(i int) *object {
+	v := s.dst.v
+	for _, index := range s.typ.Fields[i].Index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(index)
+	}
+
+	field := &object{v.Type(), v}
+	if !field.IsAssignable() {
+		return nil
+	}
+	return field
+}
+
+// Collect remembers the value of key for Finish if it belongs to a field with aliases or unknown keys are checked.
+func (s *jsonStruct) Collect(key string, obj interface{}) {
+	if !s.ctx.Options.Strict && !s.typ.Aliases[key] {
+		return
+	}
+	if s.src == nil {
+		s.src = make(map[string]interface{})
+	}
+	s.src[key] = obj
+}
+
+// Finish assigns the collected values and default values of all fields missing in the document, checks for unknown keys in strict mode and calls AfterLoad.
+func (s *jsonStruct) Finish() error {
+	for i := range s.typ.Fields {
+		if s.Found[i] {
+			continue
+		}
+		field := s.field(i)
+		if field == nil {
+			continue
 		}
 
-		if obj, ok := src[fieldName]; ok {
-			if err := fromJSON(obj, prefix.Field(fieldName), &object{val.Type(), val}, &tag); err != nil {
+		tag := s.Tag(i)
+		obj, ok, err := lookupJSONKey(s.ctx, s.prefix, s.src, tag)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := fromJSON(s.ctx, obj, s.prefix.Field(tag.JSONName), field, tag); err != nil {
+				return err
+			}
+		} else if !s.ctx.Patch {
+			// fields missing in the document get their default values
+			if err := applyDefaults(s.ctx, s.prefix.Field(tag.JSONName), field, tag); err != nil {
 				return err
 			}
 		}
 	}
+
+	if s.ctx.Options.Strict {
+		knownKeys := make([]string, 0, len(s.typ.Fields))
+		for i := range s.typ.Fields {
+			if s.field(i) == nil {
+				continue
+			}
+			tag := s.Tag(i)
+			knownKeys = append(knownKeys, tag.JSONName)
+			for _, alias := range tag.Aliases {
+				knownKeys = append(knownKeys, alias.Name)
+			}
+		}
+		if err := checkUnknownKeys(s.prefix, s.src, knownKeys); err != nil {
+			return err
+		}
+	}
+	s.releaseInline()
+	return callAfterLoad(s.prefix, s.dst)
+}
+
+// lookupJSONKey returns the value of src for the field described by tag or any of its aliases.
+func lookupJSONKey(ctx *loadContext, prefix pathPrefix, src map[string]interface{}, tag *tag) (interface{}, bool, error) {
+	obj, found := src[tag.JSONName]
+	foundKey := tag.JSONName
+	for _, alias := range tag.Aliases {
+		val, ok := src[alias.Name]
+		if !ok {
+			continue
+		}
+
+		if alias.Deprecated {
+			ctx.Warn("key %q is deprecated, use %q instead", prefix.Field(alias.Name).String(), prefix.Field(tag.JSONName).String())
+		}
+		if !found {
+			obj, foundKey, found = val, alias.Name, true
+		} else if !reflect.DeepEqual(val, obj) {
+			return nil, false, fmt.Errorf("%s: conflicting values in keys %q and %q", prefix.String(), foundKey, alias.Name)
+		}
+	}
+	return obj, found, nil
+}
+
+// checkUnknownKeys returns an error listing all keys of src that are not contained in knownKeys.
+func checkUnknownKeys(prefix pathPrefix, src map[string]interface{}, knownKeys []string) error {
+	known := make(map[string]bool)
+	for _, key := range knownKeys {
+		known[key] = true
+	}
+
+	unknownKeys := make([]string, 0)
+	for key := range src {
+		if known[key] {
+			continue
+		}
+
+		if suggestion, ok := closestMatch(key, knownKeys); ok {
+			unknownKeys = append(unknownKeys, fmt.Sprintf("%q (did you mean %q?)", key, suggestion))
+		} else {
+			unknownKeys = append(unknownKeys, fmt.Sprintf("%q", key))
+		}
+	}
+
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		if len(prefix) == 0 {
+			return fmt.Errorf("unknown keys %s", strings.Join(unknownKeys, ", "))
+		}
+		return fmt.Errorf("%s: unknown keys %s", prefix.String(), strings.Join(unknownKeys, ", "))
+	}
 	return nil
 }
 
-func sliceFromJSON(obj interface{}, prefix pathPrefix, dst *object) error {
+func sliceFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object) error {
 	t := reflect.TypeOf(obj)
 	if t.Kind() != reflect.Slice {
 		return fmt.Errorf("%s: cannot parse slice from type %T", prefix.String(), obj)
@@ -131,14 +486,14 @@ func sliceFromJSON(obj interface{}, prefix pathPrefix, dst *object) error {
 	dst.InitSlice(itemCount)
 	for i := 0; i < itemCount; i++ {
 		val := dst.v.Index(i)
-		if err := fromJSON(v.Index(i).Interface(), prefix.Index(i), &object{val.Type(), val}, nil); err != nil {
+		if err := fromJSON(ctx, v.Index(i).Interface(), prefix.Index(i), &object{val.Type(), val}, nil); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func arrayFromJSON(obj interface{}, prefix pathPrefix, dst *object) error {
+func arrayFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object) error {
 	t := reflect.TypeOf(obj)
 	if t.Kind() != reflect.Slice {
 		return fmt.Errorf("%s: cannot parse array from type %T", prefix.String(), obj)
@@ -153,30 +508,162 @@ func arrayFromJSON(obj interface{}, prefix pathPrefix, dst *object) error {
 
 	for i := 0; i < itemCount; i++ {
 		val := dst.v.Index(i)
-		if err := fromJSON(v.Index(i).Interface(), prefix.Index(i), &object{val.Type(), val}, nil); err != nil {
+		if err := fromJSON(ctx, v.Index(i).Interface(), prefix.Index(i), &object{val.Type(), val}, nil); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func stringFromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
-	return dst.SetString(obj.(string))
+// jsonString returns obj with all variable references expanded if it is a string.
+func jsonString(ctx *loadContext, obj interface{}, prefix pathPrefix) (string, bool, error) {
+	strVal, ok := obj.(string)
+	if !ok {
+		return "", false, nil
+	}
+	strVal, err := ctx.Expand(strVal)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	ctx.Resolve(prefix, strVal)
+	return strVal, true, nil
+}
+
+func stringFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse string from type %T", prefix.String(), obj)
+	}
+	return dst.SetString(strVal)
+}
+
+func boolFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	boolVal, ok := obj.(bool)
+	if !ok {
+		return fmt.Errorf("%s: expected boolean", prefix.String())
+	}
+	ctx.ResolveValue(prefix, obj)
+	return dst.SetBool(boolVal)
+}
+
+func intFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	// all numbers are decoded as float64
+	num, ok := obj.(float64)
+	if !ok {
+		return fmt.Errorf("%s: expected number", prefix.String())
+	}
+	ctx.ResolveValue(prefix, obj)
+	return dst.SetInt(int(num))
+}
+
+func enumFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse %s from type %T", prefix.String(), dst.t, obj)
+	}
+	if err := dst.SetEnumFromString(strVal); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
+}
+
+func networkFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse %s from type %T", prefix.String(), dst.t, obj)
+	}
+	if err := dst.SetNetworkValueFromString(strVal); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
 }
 
-func boolFromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
-	return dst.SetBool(obj.(bool))
+func bytesFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse bytes from type %T", prefix.String(), obj)
+	}
+
+	var encoding string
+	if tag != nil {
+		encoding = tag.Encoding
+	}
+	if err := dst.SetBytesFromString(strVal, encoding); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
 }
 
-func intFromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
-	// json.Unmarshal treats all numbers as float64
-	return dst.SetInt(int(obj.(float64)))
+func dateTimeFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	var layout, timeZone string
+	if tag != nil {
+		layout, timeZone = tag.Layout, tag.TimeZone
+	}
+
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if num, isNum := obj.(float64); isNum && (layout == layoutUnix || layout == layoutUnixMilli) {
+		// unix timestamps can also be given as numbers
+		strVal, ok = strconv.FormatFloat(num, 'f', -1, 64), true
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse datetime from type %T", prefix.String(), obj)
+	}
+	if err := dst.SetDateTimeFromStringWithFormat(strVal, layout, timeZone); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
 }
 
-func dateTimeFromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
-	return dst.SetDateTimeFromString(obj.(string))
+func durationFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse duration from type %T", prefix.String(), obj)
+	}
+	if tag != nil && tag.Calendar {
+		err = dst.SetCalendarDurationFromString(strVal)
+	} else {
+		err = dst.SetDurationFromString(strVal)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
 }
 
-func durationFromJSON(obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
-	return dst.SetDurationFromString(obj.(string))
+func byteSizeFromJSON(ctx *loadContext, obj interface{}, prefix pathPrefix, dst *object, tag *tag) error {
+	if num, ok := obj.(float64); ok {
+		// plain numbers denote bytes
+		ctx.ResolveValue(prefix, num)
+		return dst.SetInt(int(num))
+	}
+
+	strVal, ok, err := jsonString(ctx, obj, prefix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: cannot parse byte size from type %T", prefix.String(), obj)
+	}
+	if err := dst.SetByteSizeFromString(strVal); err != nil {
+		return fmt.Errorf("%s: %s", prefix.String(), err.Error())
+	}
+	return nil
 }
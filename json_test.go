@@ -1,8 +1,15 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -46,6 +53,126 @@ func TestFromJSONSimple(t *testing.T) {
 	}
 }
 
+func TestFromJSONTypeMismatch(t *testing.T) {
+	var conf JSONTestSimple
+	assert.EqualError(t, FromJSON([]byte(`{"IntData":"42"}`), &conf), `1:12: IntData: expected number`)
+	assert.EqualError(t, FromJSON([]byte(`{"BoolDataT":1}`), &conf), `1:14: BoolDataT: expected boolean`)
+	assert.EqualError(t, FromJSON([]byte(`{"IntData":`), &conf), `1:12: IntData: unexpected end of input, expected value`)
+}
+
+func TestFromFilePosition(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"config.json": "{\n  \"Main\": {\n    \"Host\": \"localhost\",\n    \"Port\": \"8080\"\n  },\n  \"Tags\": [\"a\", 1]\n}",
+	}, func(dir string) {
+		var conf struct {
+			Main struct {
+				Host string
+				Port int
+			}
+			Tags []string
+		}
+		err := FromFile(filepath.Join(dir, "config.json"), &conf)
+		assert.EqualError(t, err, filepath.Join(dir, "config.json")+":4:13: Main.Port: expected number")
+
+		var sourceErr *sourceError
+		if assert.True(t, errors.As(err, &sourceErr)) {
+			assert.Equal(t, jsonPos{4, 13}, sourceErr.Source.Pos)
+		}
+
+		conf.Main.Port = 0
+		err = FromJSON([]byte("{\n  \"Main\": {\"Port\": 1},\n  \"Tags\": [\"a\", 1]\n}"), &conf)
+		assert.EqualError(t, err, "3:17: Tags[1]: cannot parse string from type float64")
+	})
+}
+
+type JSONTestProfile struct {
+	Host    string
+	Port    int
+	Debug   bool `config:"default:true,default.prod:false"`
+	Servers []string
+	Nested  struct {
+		User     string
+		Password string
+	}
+}
+
+func TestFromFileProfile(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"config.json":      `{"Host":"localhost","Port":8080,"Servers":["a"],"Nested":{"User":"dev","Password":"secret"}}`,
+		"config.prod.json": `{"Host":"example.com","Servers":["b","c"],"Nested":{"User":"prod"}}`,
+	}, func(dir string) {
+		var conf JSONTestProfile
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf)) {
+			assert.Equal(t, "localhost", conf.Host)
+			assert.True(t, conf.Debug)
+			assert.Equal(t, []string{"a"}, conf.Servers)
+		}
+
+		conf = JSONTestProfile{}
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf, WithProfile("prod"))) {
+			assert.Equal(t, "example.com", conf.Host)
+			assert.Equal(t, 8080, conf.Port)
+			assert.False(t, conf.Debug)
+			assert.Equal(t, []string{"b", "c"}, conf.Servers)
+			assert.Equal(t, "prod", conf.Nested.User)
+			assert.Equal(t, "secret", conf.Nested.Password)
+		}
+
+		withMockEnv(func(env map[string]string) {
+			env["MAIN_PROFILE"] = "prod"
+
+			conf = JSONTestProfile{}
+			if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf, WithProfileEnv("MAIN_PROFILE"), WithSliceMerge(SliceMergeAppend))) {
+				assert.Equal(t, "example.com", conf.Host)
+				assert.Equal(t, []string{"a", "b", "c"}, conf.Servers)
+			}
+		})
+
+		// profiles without overlay file only affect default values
+		conf = JSONTestProfile{}
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.json"), &conf, WithProfile("staging"))) {
+			assert.Equal(t, "localhost", conf.Host)
+		}
+	})
+}
+
+type JSONTestPatch struct {
+	Name    string `config:"default:app"`
+	Port    int    `config:"min:1"`
+	Servers []string
+	Limits  *struct {
+		Connections int
+		Rate        int
+	}
+}
+
+func TestFromJSONPatch(t *testing.T) {
+	conf := JSONTestPatch{Name: "service", Port: 8080, Servers: []string{"a", "b"}}
+	conf.Limits = &struct {
+		Connections int
+		Rate        int
+	}{10, 5}
+	limits := conf.Limits
+
+	if assert.NoError(t, FromJSONPatch(&conf, []byte(`{"Name":null,"Servers":["c"],"Limits":{"Rate":50}}`))) {
+		// null resets to the zero value without applying defaults
+		assert.Equal(t, "", conf.Name)
+		assert.Equal(t, 8080, conf.Port)
+		assert.Equal(t, []string{"c"}, conf.Servers)
+		assert.Equal(t, 10, conf.Limits.Connections)
+		assert.Equal(t, 50, conf.Limits.Rate)
+		// previous values are not modified
+		assert.Equal(t, 5, limits.Rate)
+	}
+
+	assert.EqualError(t, FromJSONPatch(&conf, []byte(`{"Port":0,"Limits":{"Rate":1}}`)), "Port: must be at least 1")
+	assert.Equal(t, 8080, conf.Port)
+	assert.Equal(t, 50, conf.Limits.Rate)
+
+	assert.EqualError(t, FromJSONPatch(&conf, []byte(`{"Portt":1}`), WithStrictMode()), `1:1: unknown keys "Portt" (did you mean "Port"?)`)
+	assert.EqualError(t, FromJSONPatch(conf, []byte(`{}`)), "conf must be a non-nil pointer")
+}
+
 func TestFromJSONDate(t *testing.T) {
 	var conf time.Time
 	if assert.NoError(t, FromJSON([]byte(`"2020-02-25T17:20:34Z"`), &conf)) {
@@ -53,11 +180,132 @@ func TestFromJSONDate(t *testing.T) {
 	}
 }
 
+type JSONTestDateTimeFormat struct {
+	Unix      time.Time `config:"layout:unix"`
+	UnixMilli time.Time `config:"layout:unixms"`
+	Layout    time.Time `config:"layout:2006/01/02,tz:UTC"`
+}
+
+func TestFromJSONDateTimeFormat(t *testing.T) {
+	var conf JSONTestDateTimeFormat
+	if assert.NoError(t, FromJSON([]byte(`{"Unix":1582651234,"UnixMilli":"1582651234500","Layout":"2020/02/25"}`), &conf)) {
+		assert.Equal(t, time.Date(2020, time.February, 25, 17, 20, 34, 0, time.UTC), conf.Unix.UTC())
+		assert.Equal(t, time.Date(2020, time.February, 25, 17, 20, 34, 500000000, time.UTC), conf.UnixMilli.UTC())
+		assert.Equal(t, time.Date(2020, time.February, 25, 0, 0, 0, 0, time.UTC), conf.Layout)
+	}
+
+	assert.EqualError(t, FromJSON([]byte(`{"Layout":42}`), &conf), "1:11: Layout: cannot parse datetime from type float64")
+	assert.EqualError(t, FromJSON([]byte(`{"Layout":"25.02.2020"}`), &conf), `1:11: Layout: cannot parse datetime from "25.02.2020": parsing time "25.02.2020" as "2006/01/02": cannot parse "25.02.2020" as "2006"`)
+}
+
+type JSONTestBytes struct {
+	Base64 []byte
+	Hex    []byte `config:"encoding:hex"`
+}
+
+func TestFromJSONBytes(t *testing.T) {
+	var conf JSONTestBytes
+	if assert.NoError(t, FromJSON([]byte(`{"Base64":"AQID/w==","Hex":"010203ff"}`), &conf)) {
+		assert.Equal(t, []byte{1, 2, 3, 255}, conf.Base64)
+		assert.Equal(t, []byte{1, 2, 3, 255}, conf.Hex)
+	}
+
+	assert.EqualError(t, FromJSON([]byte(`{"Base64":[1,2,3]}`), &conf), "1:11: Base64: cannot parse bytes from type []interface {}")
+}
+
+type JSONTestNetwork struct {
+	URL      *url.URL
+	Networks []net.IPNet
+}
+
+func TestFromJSONNetwork(t *testing.T) {
+	var conf JSONTestNetwork
+	if assert.NoError(t, FromJSON([]byte(`{"URL":"https://example.com","Networks":["10.0.0.0/8","fd00::/8"]}`), &conf)) {
+		if assert.NotNil(t, conf.URL) {
+			assert.Equal(t, "example.com", conf.URL.Host)
+		}
+		if assert.Len(t, conf.Networks, 2) {
+			assert.Equal(t, "fd00::/8", conf.Networks[1].String())
+		}
+	}
+
+	assert.EqualError(t, FromJSON([]byte(`{"Networks":["10.0.0.0/8","10.0.0.1"]}`), &conf), `1:27: Networks[1]: cannot parse network from "10.0.0.1": expected CIDR notation like 10.0.0.0/8`)
+	assert.EqualError(t, FromJSON([]byte(`{"URL":42}`), &conf), "1:8: URL: cannot parse url.URL from type float64")
+}
+
+func TestFromJSONEnum(t *testing.T) {
+	var conf EnvTestEnum
+	if assert.NoError(t, FromJSON([]byte(`{"Level":"warn","Levels":["error","Debug"]}`), &conf)) {
+		assert.Equal(t, EnumTestWarn, conf.Level)
+		assert.Equal(t, []EnumTestLevel{EnumTestError, EnumTestDebug}, conf.Levels)
+	}
+
+	assert.EqualError(t, FromJSON([]byte(`{"Level":2}`), &conf), "1:10: Level: cannot parse config.EnumTestLevel from type float64")
+}
+
+type JSONTestInterpolation struct {
+	Port    int
+	Listen  string
+	DataDir string
+}
+
+func TestFromJSONInterpolation(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["HOME"] = "/home/user"
+
+		var conf JSONTestInterpolation
+		if assert.NoError(t, FromJSON([]byte(`{"Port":8080,"Listen":":${Port}","DataDir":"${HOME}/data"}`), &conf)) {
+			assert.Equal(t, ":8080", conf.Listen)
+			assert.Equal(t, "/home/user/data", conf.DataDir)
+		}
+
+		assert.EqualError(t, FromJSON([]byte(`{"Listen":"${Host}:${Port}"}`), &conf), `1:11: Listen: undefined variable "Host"`)
+
+		// references with escaped characters are found as well
+		conf = JSONTestInterpolation{}
+		if assert.NoError(t, FromJSON([]byte(`{"Port":8080,"Listen":":\u0024{Port}"}`), &conf)) {
+			assert.Equal(t, ":8080", conf.Listen)
+		}
+	})
+}
+
+type JSONTestDefaults struct {
+	Name    string `config:"default:app"`
+	Port    int    `config:"default:8080"`
+	DataDir string `json:"data_dir" config:"default:/var/lib/${Name}"`
+	Nested  EnvTestDefault
+}
+
+func TestFromJSONDefaults(t *testing.T) {
+	var conf JSONTestDefaults
+	if assert.NoError(t, FromJSON([]byte(`{"Port":0,"Nested":{"StringData":"json"}}`), &conf)) {
+		assert.Equal(t, "app", conf.Name)
+		// values present in the document are not replaced
+		assert.Equal(t, 0, conf.Port)
+		assert.Equal(t, "/var/lib/app", conf.DataDir)
+		assert.Equal(t, EnvTestDefault{"json", 42, true, false}, conf.Nested)
+	}
+
+	conf = JSONTestDefaults{Name: "loaded"}
+	if assert.NoError(t, FromJSON([]byte(`{"data_dir":"/tmp"}`), &conf)) {
+		assert.Equal(t, "loaded", conf.Name)
+		assert.Equal(t, 8080, conf.Port)
+	}
+}
+
 func TestFromJSONDuration(t *testing.T) {
 	var conf time.Duration
 	if assert.NoError(t, FromJSON([]byte(`"1h34m17s"`), &conf)) {
 		assert.Equal(t, 1*time.Hour+34*time.Minute+17*time.Second, conf)
 	}
+
+	var timeout struct {
+		Timeout time.Duration
+	}
+	err := FromJSON([]byte("{\n  \"Timeout\": \"5x\"\n}"), &timeout)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "2:14: Timeout: cannot parse duration from \"5x\""), err.Error())
+	}
 }
 
 type JSONTestTag struct {
@@ -125,3 +373,189 @@ func TestFromJSONArray(t *testing.T) {
 	require.Equal(t, [1]string{"foo"}, conf.ArrayData2)
 	require.Equal(t, [2]string{"bar", "42"}, conf.ArrayData3)
 }
+
+type JSONTestStrict struct {
+	Name   string
+	Nested struct {
+		Address string
+	}
+	Ignored string `json:"-"`
+}
+
+func TestFromJSONStrict(t *testing.T) {
+	var conf JSONTestStrict
+	require.NoError(t, FromJSON([]byte(`{"Name":"foobar","Nested":{"Address":"localhost"}}`), &conf, WithStrictMode()))
+	require.Equal(t, "localhost", conf.Nested.Address)
+}
+
+func TestFromJSONStrictUnknown(t *testing.T) {
+	data := []byte(`{"Name":"foobar","Nested":{"Adress":"localhost"}}`)
+
+	var conf JSONTestStrict
+	require.NoError(t, FromJSON(data, &conf))
+
+	err := FromJSON(data, &conf, WithStrictMode())
+	require.Error(t, err)
+	require.Equal(t, `1:27: Nested: unknown keys "Adress" (did you mean "Address"?)`, err.Error())
+
+	err = FromJSON([]byte(`{"Ignored":"value"}`), &conf, WithStrictMode())
+	require.Error(t, err)
+}
+
+type JSONTestPointer struct {
+	PtrString *string
+	PtrValue  *JSONTestSimple
+	NullValue *JSONTestSimple
+}
+
+func TestFromJSONPointer(t *testing.T) {
+	conf := JSONTestPointer{NullValue: &JSONTestSimple{}}
+	require.NoError(t, FromJSON([]byte(`{"PtrValue":{"IntData":42},"NullValue":null}`), &conf))
+	require.Nil(t, conf.PtrString)
+	require.Equal(t, &JSONTestSimple{IntData: 42}, conf.PtrValue)
+	require.Nil(t, conf.NullValue)
+}
+
+type JSONTestCommon struct {
+	LogLevel string
+}
+
+type JSONTestEmbedded struct {
+	JSONTestCommon
+	Port     int
+	NoInline JSONTestCommon `config:"noinline"`
+}
+
+func TestFromJSONEmbedded(t *testing.T) {
+	var conf JSONTestEmbedded
+	require.NoError(t, FromJSON([]byte(`{"LogLevel":"debug","Port":8080,"NoInline":{"LogLevel":"info"}}`), &conf, WithStrictMode()))
+	require.Equal(t, "debug", conf.LogLevel)
+	require.Equal(t, 8080, conf.Port)
+	require.Equal(t, "info", conf.NoInline.LogLevel)
+
+	require.Error(t, FromJSON([]byte(`{"JSONTestCommon":{"LogLevel":"debug"}}`), &conf, WithStrictMode()))
+}
+
+type JSONTestEmbeddedPtr struct {
+	*JSONTestCommon
+	Port int
+}
+
+type JSONTestEmbeddedNamed struct {
+	JSONTestCommon `json:"common"`
+}
+
+func TestFromJSONEmbeddedPtr(t *testing.T) {
+	var conf JSONTestEmbeddedPtr
+	require.NoError(t, FromJSON([]byte(`{"Port":8080}`), &conf, WithStrictMode()))
+	require.Nil(t, conf.JSONTestCommon)
+	require.Equal(t, 8080, conf.Port)
+
+	require.NoError(t, FromJSON([]byte(`{"LogLevel":"debug"}`), &conf, WithStrictMode()))
+	require.Equal(t, &JSONTestCommon{LogLevel: "debug"}, conf.JSONTestCommon)
+
+	var conf2 JSONTestEmbeddedPtr
+	require.NoError(t, loadJSONTree([]byte(`{"LogLevel":"info"}`), &conf2, WithStrictMode()))
+	require.Equal(t, &JSONTestCommon{LogLevel: "info"}, conf2.JSONTestCommon)
+}
+
+func TestFromJSONEmbeddedNamed(t *testing.T) {
+	var conf JSONTestEmbeddedNamed
+	require.NoError(t, FromJSON([]byte(`{"common":{"LogLevel":"debug"}}`), &conf, WithStrictMode()))
+	require.Equal(t, "debug", conf.LogLevel)
+
+	require.Error(t, FromJSON([]byte(`{"LogLevel":"debug"}`), &conf, WithStrictMode()))
+}
+
+type JSONTestAlias struct {
+	Address string `json:"address" config:"alias:host,deprecated:addr"`
+}
+
+func TestFromJSONAlias(t *testing.T) {
+	var warnings []string
+	warningHandler := WithWarningHandler(func(msg string) { warnings = append(warnings, msg) })
+
+	var conf JSONTestAlias
+	require.NoError(t, FromJSON([]byte(`{"host":"localhost"}`), &conf, warningHandler, WithStrictMode()))
+	require.Equal(t, "localhost", conf.Address)
+	require.Empty(t, warnings)
+
+	require.NoError(t, FromJSON([]byte(`{"addr":"example.com"}`), &conf, warningHandler))
+	require.Equal(t, "example.com", conf.Address)
+	require.Equal(t, []string{`key "addr" is deprecated, use "address" instead`}, warnings)
+
+	require.Error(t, FromJSON([]byte(`{"address":"localhost","host":"example.com"}`), &conf, warningHandler))
+}
+
+// withTempFiles creates a temporary directory with the given files and removes it after f returns.
+func withTempFiles(t *testing.T, files map[string]string, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "go-config-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), os.ModePerm))
+	}
+	f(dir)
+}
+
+// benchmarkRoutingTable returns a JSON document with a routing table of the given size.
+func benchmarkRoutingTable(size int) []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"LogLevel":"info","Routes":[`)
+	for i := 0; i < size; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"Prefix":"/api/v1/resource%d","Target":"http://backend%d:8080","Weights":[%d,%d]}`, i, i%16, i%3, i%7)
+	}
+	sb.WriteString(`]}`)
+	return []byte(sb.String())
+}
+
+func BenchmarkFromJSON(b *testing.B) {
+	data := benchmarkRoutingTable(10000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var conf JSONTestDecode
+		if err := FromJSON(data, &conf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFromJSONUnmarshal loads the same document as BenchmarkFromJSON by decoding it with json.Unmarshal into maps and slices first, as FromJSON did before decoding in a single pass.
+func BenchmarkFromJSONUnmarshal(b *testing.B) {
+	data := benchmarkRoutingTable(10000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var obj interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			b.Fatal(err)
+		}
+		var conf JSONTestDecode
+		if err := loadJSON(newLoadContext(nil), obj, &conf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFromFile(b *testing.B) {
+	data := benchmarkRoutingTable(10000)
+	path := filepath.Join(b.TempDir(), "routes.json")
+	if err := ioutil.WriteFile(path, data, os.ModePerm); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var conf JSONTestDecode
+		if err := FromFile(path, &conf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// jsonDecoder assigns a JSON document to a configuration in a single pass without building an intermediate tree of maps and slices.
+type jsonDecoder struct {
+	jsonParser
+	ctx *loadContext
+	// file is the path of the document, empty if it is not read from a file.
+	file string
+}
+
+// decodeJSON parses data read from file, which may be empty, and assigns it to conf.
+//
+// Structs, slices and arrays are filled directly from the token stream. All other values are parsed as a whole and assigned by fromJSON.
+func decodeJSON(ctx *loadContext, file string, data []byte, conf interface{}) error {
+	dst := newObject(conf)
+	if !dst.IsAssignable() {
+		return fmt.Errorf("conf must be an assignable value")
+	}
+
+	ctx.RecordDocument(file, data)
+	ctx.TrackReferences(dst.t)
+	d := &jsonDecoder{jsonParser{data: data, relaxed: ctx.RelaxedJSON(file), path: newPathPrefix("")}, ctx, file}
+	rootPrefix := newPathPrefix("")
+	if err := d.decodeDocument(rootPrefix, dst); err != nil {
+		if _, ok := err.(*jsonSyntaxError); ok && len(file) > 0 {
+			return fmt.Errorf("%s:%s", file, err.Error())
+		}
+		return err
+	}
+	if ctx.Options.NoValidation {
+		return nil
+	}
+	return validate(rootPrefix, dst, nil)
+}
+
+// decodeDocument assigns the top-level value to dst and rejects trailing data.
+func (d *jsonDecoder) decodeDocument(prefix pathPrefix, dst *object) error {
+	if err := d.skipSpace(); err != nil {
+		return err
+	}
+	if err := d.decode(prefix, dst, nil); err != nil {
+		return err
+	}
+	if err := d.skipSpace(); err != nil {
+		return err
+	}
+	if d.pos < len(d.data) {
+		return d.errorf("unexpected %s after top-level value", d.describe())
+	}
+	return nil
+}
+
+// decode assigns the value at the current position to dst and annotates errors with the position of the value.
+func (d *jsonDecoder) decode(prefix pathPrefix, dst *object, tag *tag) error {
+	start := d.pos
+	if err := d.decodeValue(prefix, dst, tag); err != nil {
+		switch err.(type) {
+		case *sourceError, *jsonSyntaxError:
+			return err
+		}
+		return &sourceError{jsonSource{d.file, d.position(start)}, err}
+	}
+	return nil
+}
+
+func (d *jsonDecoder) decodeValue(prefix pathPrefix, dst *object, tag *tag) error {
+	if d.pos < len(d.data) && leafFromJSONFunc(dst, tag) == nil {
+		switch c := d.data[d.pos]; {
+		case dst.Kind() == reflect.Ptr && (c == '{' || c == '['):
+			return d.decode(prefix, dst.AllocElem(), tag)
+		case dst.Kind() == reflect.Struct && c == '{':
+			return d.decodeStruct(prefix, dst)
+		case dst.Kind() == reflect.Slice && c == '[':
+			return d.decodeSlice(prefix, dst)
+		case dst.Kind() == reflect.Array && c == '[':
+			return d.decodeArray(prefix, dst)
+		}
+	}
+
+	// single values and type mismatches are handled like decoded documents
+	obj, err := d.parseValue()
+	if err != nil {
+		return err
+	}
+	return fromJSON(d.ctx, obj, prefix, dst, tag)
+}
+
+func (d *jsonDecoder) decodeStruct(prefix pathPrefix, dst *object) error {
+	s := newJSONStruct(d.ctx, prefix, dst, nil)
+	// values of fields before their first key, to replace them on duplicate keys
+	var originals map[int]reflect.Value
+	if err := d.parseMembers(func(key string) error {
+		i, field := s.Field(key)
+		if field == nil {
+			obj, err := d.parseValue()
+			if err != nil {
+				return err
+			}
+			s.Collect(key, obj)
+			return nil
+		}
+
+		if !s.Found[i] {
+			s.Found[i] = true
+			if !field.v.IsZero() {
+				if originals == nil {
+					originals = make(map[int]reflect.Value)
+				}
+				originals[i] = deepCopy(field.v)
+			}
+		} else if original, ok := originals[i]; ok {
+			// the last of duplicate keys wins like for decoded documents
+			field.v.Set(deepCopy(original))
+		} else {
+			field.v.Set(reflect.Zero(field.t))
+		}
+		// the parser path equals prefix.Field(key) and is reused to save allocations
+		return d.decode(d.path, field, s.Tag(i))
+	}); err != nil {
+		return err
+	}
+	return s.Finish()
+}
+
+func (d *jsonDecoder) decodeSlice(prefix pathPrefix, dst *object) error {
+	items := reflect.MakeSlice(dst.t, 0, 0)
+	if err := d.parseElements(func(i int) error {
+		items = reflect.Append(items, reflect.Zero(dst.t.Elem()))
+		item := items.Index(i)
+		return d.decode(d.path, &object{item.Type(), item}, nil)
+	}); err != nil {
+		return err
+	}
+	dst.v.Set(items)
+	return nil
+}
+
+func (d *jsonDecoder) decodeArray(prefix pathPrefix, dst *object) error {
+	itemCount := 0
+	if err := d.parseElements(func(i int) error {
+		itemCount++
+		if i >= dst.Len() {
+			// surplus items are only counted for the error message
+			_, err := d.parseValue()
+			return err
+		}
+		return d.decode(d.path, dst.Index(i), nil)
+	}); err != nil {
+		return err
+	}
+
+	if itemCount != dst.Len() {
+		return fmt.Errorf("%s: expected %d array items, but got %d", prefix.String(), dst.Len(), itemCount)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type JSONTestDecode struct {
+	JSONTestCommon
+	Address  string `json:"address" config:"alias:host"`
+	Timeout  time.Duration
+	Ptr      *JSONTestSimple
+	Routes   []JSONTestRoute
+	Fixed    [2]int
+	Defaults EnvTestDefault
+}
+
+type JSONTestRoute struct {
+	Prefix  string
+	Target  string
+	Weights []int
+}
+
+// loadJSONTree loads data by decoding the whole document first like FromConfigDir.
+func loadJSONTree(data []byte, conf interface{}, opts ...Option) error {
+	ctx := newLoadContext(opts)
+	obj, err := ctx.ParseJSON(data, "")
+	if err != nil {
+		return err
+	}
+	ctx.RecordDocument("", data)
+	return loadJSON(ctx, obj, conf)
+}
+
+func TestDecodeJSONMatchesTree(t *testing.T) {
+	docs := []string{
+		`{"LogLevel":"debug","host":"localhost","Timeout":"5s","Ptr":{"IntData":1},"Routes":[{"Prefix":"/a","Weights":[1,2]},{"Target":"b"}],"Fixed":[1,2],"Defaults":{"IntData":1}}`,
+		`{"address":"a","host":"a","Routes":[],"Ptr":null}`,
+		`{"Unknown":{"Nested":[1,2]},"Routes":null}`,
+		`{"address":"a","host":"b"}`,
+		`{"Timeout":true}`,
+		`{"Ptr":{"IntData":"1"}}`,
+		`{"Routes":{"Prefix":"/"}}`,
+		"{\n\"Routes\":[\n{\"Weights\":[1,\"2\"]}\n]\n}",
+		`{"Fixed":[1,2,3]}`,
+		`{"Fixed":[1]}`,
+		`{"Defaults":"x"}`,
+		`{"host":{"a":[1,{"b":2}]}}`,
+		`[]`,
+		`{"LogLevel":"debug",}`,
+		`{"Defaults":{"StringData":"x"},"Defaults":{"IntData":1}}`,
+		`{"Ptr":{"StringData":"x"},"Ptr":{"IntData":1},"Routes":[{"Prefix":"a"}],"Routes":[],"LogLevel":"a","LogLevel":"b"}`,
+		`{"Fixed":[1,2],"Fixed":[3],"host":"a","host":"b"}`,
+	}
+	for _, doc := range docs {
+		for _, opts := range [][]Option{nil, {WithStrictMode()}} {
+			// duplicate keys must also reset values loaded before
+			expected := JSONTestDecode{Ptr: &JSONTestSimple{BoolDataT: true}, Defaults: EnvTestDefault{StringData: "keep"}}
+			actual := JSONTestDecode{Ptr: &JSONTestSimple{BoolDataT: true}, Defaults: EnvTestDefault{StringData: "keep"}}
+			expectedErr := loadJSONTree([]byte(doc), &expected, opts...)
+			actualErr := decodeJSON(newLoadContext(opts), "", []byte(doc), &actual)
+			if expectedErr != nil {
+				assert.EqualError(t, actualErr, expectedErr.Error(), doc)
+			} else if assert.NoError(t, actualErr, doc) {
+				assert.Equal(t, expected, actual, doc)
+			}
+		}
+	}
+}
+
+func TestDecodeJSONKeepsValues(t *testing.T) {
+	conf := JSONTestDecode{Address: "keep", Ptr: &JSONTestSimple{StringData: "keep"}}
+	if assert.NoError(t, FromJSON([]byte(`{"Ptr":{"IntData":1}}`), &conf)) {
+		assert.Equal(t, "keep", conf.Address)
+		assert.Equal(t, "keep", conf.Ptr.StringData)
+		assert.Equal(t, 1, conf.Ptr.IntData)
+	}
+}
@@ -0,0 +1,633 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonSyntaxError describes invalid JSON at a position in the document.
+type jsonSyntaxError struct {
+	Line, Column int
+	// Path is the location of the invalid value in the document, empty for the root value.
+	Path string
+	Msg  string
+}
+
+func (e *jsonSyntaxError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Msg)
+}
+
+// maxJSONDepth is the maximum nesting depth of objects and arrays, like in encoding/json.
+const maxJSONDepth = 10000
+
+// jsonParser decodes JSON documents into the same values as json.Unmarshal into an interface{}.
+//
+// In relaxed mode, the JSON5 extensions comments, trailing commas, unquoted keys, single quoted strings, hexadecimal numbers, leading or trailing decimal points, explicit plus signs, Infinity and NaN are accepted.
+type jsonParser struct {
+	data    []byte
+	pos     int
+	relaxed bool
+	path    pathPrefix
+	// depth is the number of objects and arrays containing the current position.
+	depth int
+	// lineStarts contains the offsets of all lines in data once needed.
+	lineStarts []int
+}
+
+// jsonPos is the position of a value in a JSON document.
+type jsonPos struct {
+	Line, Column int
+}
+
+// parseJSON decodes data, which must contain exactly one JSON value.
+func parseJSON(data []byte, relaxed bool) (interface{}, error) {
+	p := &jsonParser{data: data, relaxed: relaxed, path: newPathPrefix("")}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	val, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected %s after top-level value", p.describe())
+	}
+	return val, nil
+}
+
+// locateJSON returns the position of the value at path in the valid JSON document data, or false if the document does not contain it. Like for decoding, the last of duplicate keys is used.
+//
+// Positions are only needed for error messages, so they are searched on demand instead of being recorded for all values while parsing.
+func locateJSON(data []byte, relaxed bool, path pathPrefix) (jsonPos, bool) {
+	p := &jsonParser{data: data, relaxed: relaxed, path: newPathPrefix("")}
+	if err := p.skipSpace(); err != nil {
+		return jsonPos{}, false
+	}
+	for _, pathPart := range path {
+		if p.pos >= len(p.data) {
+			return jsonPos{}, false
+		}
+
+		found := -1
+		var err error
+		switch part := pathPart.(type) {
+		case fieldName:
+			if p.data[p.pos] != '{' {
+				return jsonPos{}, false
+			}
+			err = p.parseMembers(func(key string) error {
+				if key == part.RealName {
+					found = p.pos
+				}
+				return p.skipValue()
+			})
+		case int:
+			if p.data[p.pos] != '[' {
+				return jsonPos{}, false
+			}
+			err = p.parseElements(func(i int) error {
+				if i == part {
+					found = p.pos
+				}
+				return p.skipValue()
+			})
+		}
+		if err != nil || found < 0 {
+			return jsonPos{}, false
+		}
+		p.pos = found
+	}
+	return p.position(p.pos), true
+}
+
+// position returns the line and column of the byte offset pos, both starting at 1. Columns count characters.
+func (p *jsonParser) position(pos int) jsonPos {
+	if p.lineStarts == nil {
+		p.lineStarts = []int{0}
+		for i, c := range p.data {
+			if c == '\n' {
+				p.lineStarts = append(p.lineStarts, i+1)
+			}
+		}
+	}
+	if pos > len(p.data) {
+		pos = len(p.data)
+	}
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > pos })
+	return jsonPos{line, utf8.RuneCount(p.data[p.lineStarts[line-1]:pos]) + 1}
+}
+
+// errorf returns a syntax error at the current position.
+func (p *jsonParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+func (p *jsonParser) errorAt(pos int, format string, args ...interface{}) error {
+	position := p.position(pos)
+	return &jsonSyntaxError{position.Line, position.Column, p.path.String(), fmt.Sprintf(format, args...)}
+}
+
+// describe returns a readable representation of the character at the current position.
+func (p *jsonParser) describe() string {
+	if p.pos >= len(p.data) {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return fmt.Sprintf("character %q", r)
+}
+
+// skipSpace skips whitespace and, in relaxed mode, comments.
+func (p *jsonParser) skipSpace() error {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case p.relaxed && c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case p.relaxed && c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		case p.relaxed && c >= utf8.RuneSelf:
+			// JSON5 accepts all unicode whitespace
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			if !unicode.IsSpace(r) && r != '\uFEFF' {
+				return nil
+			}
+			p.pos += size
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *jsonParser) parseValue() (interface{}, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input, expected value")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || (p.relaxed && c == '\''):
+		return p.parseString()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	}
+
+	word := p.peekIdentifier()
+	switch {
+	case word == "true":
+		p.pos += len(word)
+		return true, nil
+	case word == "false":
+		p.pos += len(word)
+		return false, nil
+	case word == "null":
+		p.pos += len(word)
+		return nil, nil
+	case p.relaxed && (word == "Infinity" || word == "NaN"):
+		return p.parseNumber()
+	}
+	return nil, p.errorf("unexpected %s, expected value", p.describe())
+}
+
+// skipValue consumes the value at the current position without decoding objects and arrays.
+func (p *jsonParser) skipValue() error {
+	if p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '{':
+			return p.parseMembers(func(key string) error {
+				return p.skipValue()
+			})
+		case '[':
+			return p.parseElements(func(i int) error {
+				return p.skipValue()
+			})
+		}
+	}
+	_, err := p.parseValue()
+	return err
+}
+
+func (p *jsonParser) parseObject() (interface{}, error) {
+	obj := make(map[string]interface{})
+	err := p.parseMembers(func(key string) error {
+		val, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		obj[key] = val
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// enter increases the nesting depth when an object or array starts. leave must be called when it ends.
+func (p *jsonParser) enter() error {
+	if p.depth >= maxJSONDepth {
+		return p.errorf("exceeded max nesting depth of %d", maxJSONDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *jsonParser) leave() {
+	p.depth--
+}
+
+// parseMembers consumes an object and calls f with the current position at the start of each member value. f must consume the value.
+func (p *jsonParser) parseMembers(f func(key string) error) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	p.pos++
+	for memberCount := 0; ; memberCount++ {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			if memberCount > 0 && !p.relaxed {
+				// trailing comma after the last member
+				return p.errorf("unexpected character '}', expected object key")
+			}
+			p.pos++
+			return nil
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return p.errorf("unexpected %s, expected ':' after object key", p.describe())
+		}
+		p.pos++
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+
+		p.path = p.path.Field(key)
+		if err := f(key); err != nil {
+			return err
+		}
+		p.path = p.path[:len(p.path)-1]
+
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos >= len(p.data) {
+			return p.errorf("unexpected end of input, expected ',' or '}'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return nil
+		default:
+			return p.errorf("unexpected %s, expected ',' or '}'", p.describe())
+		}
+	}
+}
+
+func (p *jsonParser) parseKey() (string, error) {
+	if p.pos < len(p.data) {
+		if c := p.data[p.pos]; c == '"' || (p.relaxed && c == '\'') {
+			return p.parseString()
+		}
+		if p.relaxed {
+			if key := p.peekIdentifier(); len(key) > 0 {
+				p.pos += len(key)
+				return key, nil
+			}
+		}
+	}
+	return "", p.errorf("unexpected %s, expected object key", p.describe())
+}
+
+// peekIdentifier returns the ECMAScript identifier at the current position without consuming it.
+func (p *jsonParser) peekIdentifier() string {
+	end := p.pos
+	for end < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[end:])
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (end > p.pos && unicode.IsDigit(r))) {
+			break
+		}
+		end += size
+	}
+	return string(p.data[p.pos:end])
+}
+
+func (p *jsonParser) parseArray() (interface{}, error) {
+	arr := make([]interface{}, 0)
+	err := p.parseElements(func(i int) error {
+		val, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		arr = append(arr, val)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// parseElements consumes an array and calls f with the current position at the start of each item. f must consume the item.
+func (p *jsonParser) parseElements(f func(i int) error) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	p.pos++
+	for i := 0; ; i++ {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' && (i == 0 || p.relaxed) {
+			p.pos++
+			return nil
+		}
+
+		p.path = p.path.Index(i)
+		if err := f(i); err != nil {
+			return err
+		}
+		p.path = p.path[:len(p.path)-1]
+
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos >= len(p.data) {
+			return p.errorf("unexpected end of input, expected ',' or ']'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return nil
+		default:
+			return p.errorf("unexpected %s, expected ',' or ']'", p.describe())
+		}
+	}
+}
+
+func (p *jsonParser) parseString() (string, error) {
+	quote := p.data[p.pos]
+	start := p.pos
+	p.pos++
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.data) {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			return "", p.errorf("invalid control character %q in string", c)
+		case c < utf8.RuneSelf:
+			sb.WriteByte(c)
+			p.pos++
+		default:
+			// invalid UTF-8 is replaced by U+FFFD like in encoding/json
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			if r == utf8.RuneError && size == 1 {
+				sb.WriteRune(unicode.ReplacementChar)
+			} else {
+				sb.Write(p.data[p.pos : p.pos+size])
+			}
+			p.pos += size
+		}
+	}
+}
+
+func (p *jsonParser) parseEscape(sb *strings.Builder) error {
+	escapePos := p.pos
+	p.pos++
+	if p.pos >= len(p.data) {
+		return p.errorAt(escapePos, "unterminated string")
+	}
+	c := p.data[p.pos]
+	p.pos++
+
+	switch c {
+	case '"', '\\', '/':
+		sb.WriteByte(c)
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'u':
+		r, err := p.parseHexRune(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			// characters outside the basic multilingual plane are encoded as surrogate pair. Like in encoding/json, invalid pairs result in U+FFFD and the following escape sequence is decoded on its own
+			pair := unicode.ReplacementChar
+			if r2, ok := p.peekUnicodeEscape(); ok {
+				pair = utf16.DecodeRune(r, r2)
+			}
+			if pair != unicode.ReplacementChar {
+				p.pos += 6
+			}
+			r = pair
+		}
+		sb.WriteRune(r)
+	default:
+		if !p.relaxed {
+			return p.errorAt(escapePos, "invalid escape sequence \\%c", c)
+		}
+		switch c {
+		case '\'':
+			sb.WriteByte('\'')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0':
+			sb.WriteByte(0)
+		case 'x':
+			r, err := p.parseHexRune(2)
+			if err != nil {
+				return err
+			}
+			sb.WriteRune(r)
+		case '\n':
+			// line continuation
+		case '\r':
+			if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+				p.pos++
+			}
+		default:
+			p.pos--
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			if r >= '1' && r <= '9' {
+				return p.errorAt(escapePos, "invalid escape sequence \\%c", r)
+			}
+			sb.WriteRune(r)
+			p.pos += size
+		}
+	}
+	return nil
+}
+
+// peekUnicodeEscape returns the character of the escape sequence \uXXXX at the current position without consuming it.
+func (p *jsonParser) peekUnicodeEscape() (rune, bool) {
+	if p.pos+6 > len(p.data) || p.data[p.pos] != '\\' || p.data[p.pos+1] != 'u' {
+		return 0, false
+	}
+	val, err := strconv.ParseUint(string(p.data[p.pos+2:p.pos+6]), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(val), true
+}
+
+func (p *jsonParser) parseHexRune(digits int) (rune, error) {
+	if p.pos+digits > len(p.data) {
+		return 0, p.errorf("incomplete escape sequence")
+	}
+	val, err := strconv.ParseUint(string(p.data[p.pos:p.pos+digits]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.pos += digits
+	return rune(val), nil
+}
+
+func (p *jsonParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	sign := 1.0
+	if c := p.data[p.pos]; c == '-' || (p.relaxed && c == '+') {
+		if c == '-' {
+			sign = -1
+		}
+		p.pos++
+	}
+
+	if p.relaxed {
+		switch word := p.peekIdentifier(); word {
+		case "Infinity":
+			p.pos += len(word)
+			return sign * math.Inf(1), nil
+		case "NaN":
+			p.pos += len(word)
+			return math.NaN(), nil
+		}
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '0' && (p.data[p.pos+1] == 'x' || p.data[p.pos+1] == 'X') {
+			p.pos += 2
+			digitStart := p.pos
+			for p.pos < len(p.data) && isHexDigit(p.data[p.pos]) {
+				p.pos++
+			}
+			val, err := strconv.ParseUint(string(p.data[digitStart:p.pos]), 16, 64)
+			if err != nil {
+				return nil, p.errorAt(start, "invalid number %q", string(p.data[start:p.pos]))
+			}
+			return sign * float64(val), nil
+		}
+	}
+
+	intStart := p.pos
+	p.skipDigits()
+	intDigits := p.pos - intStart
+	fracDigits := -1
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		fracStart := p.pos
+		p.skipDigits()
+		fracDigits = p.pos - fracStart
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		expStart := p.pos
+		p.skipDigits()
+		if p.pos == expStart {
+			return nil, p.errorAt(start, "invalid number %q", string(p.data[start:p.pos]))
+		}
+	}
+
+	valid := intDigits > 0 && fracDigits != 0
+	if !p.relaxed && intDigits > 1 && p.data[intStart] == '0' {
+		valid = false
+	}
+	if p.relaxed && intDigits == 0 && fracDigits > 0 {
+		// leading decimal point like .5
+		valid = true
+	}
+	if p.relaxed && intDigits > 0 && fracDigits == 0 {
+		// trailing decimal point like 5.
+		valid = true
+	}
+	if !valid {
+		if p.pos == start || (p.pos == start+1 && intDigits == 0 && fracDigits < 0) {
+			return nil, p.errorf("unexpected %s, expected value", p.describe())
+		}
+		return nil, p.errorAt(start, "invalid number %q", string(p.data[start:p.pos]))
+	}
+
+	numStr := strings.TrimPrefix(string(p.data[start:p.pos]), "+")
+	val, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return nil, p.errorAt(start, "invalid number %q", string(p.data[start:p.pos]))
+	}
+	return val, nil
+}
+
+func (p *jsonParser) skipDigits() {
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONStrict(t *testing.T) {
+	// strict mode must decode the same values as encoding/json
+	docs := []string{
+		`{"a":1,"b":[true,false,null],"c":{"d":"e"},"f":[]}`,
+		` [ -0.5e3 , 1E-2, 0, 12.25 ] `,
+		`"esc\"\\\/\b\f\n\r\tä😀"`,
+		`{}`,
+		`"äöü"`,
+		`"\ud800\u0041"`,
+		`"\udc00x\ud83d\ude00"`,
+		`"\ud800\ud800\udc00\ud800"`,
+		"\"a\xffb\xc3\"",
+		"{\"\xe2\x82\":\"\xed\xa0\x80\"}",
+		strings.Repeat("[", maxJSONDepth) + strings.Repeat("]", maxJSONDepth),
+	}
+	for _, doc := range docs {
+		var expected interface{}
+		assert.NoError(t, json.Unmarshal([]byte(doc), &expected))
+		val, err := parseJSON([]byte(doc), false)
+		if assert.NoError(t, err, doc) {
+			assert.Equal(t, expected, val, doc)
+		}
+	}
+
+	invalid := map[string]string{
+		`{"a":1,}`:                            `1:8: unexpected character '}', expected object key`,
+		`[1,2,]`:                              `1:6: [2]: unexpected character ']', expected value`,
+		`{a:1}`:                               `1:2: unexpected character 'a', expected object key`,
+		"{\"a\":1 // comment\n}":              `1:8: unexpected character '/', expected ',' or '}'`,
+		`'str'`:                               `1:1: unexpected character '\'', expected value`,
+		`0x10`:                                `1:2: unexpected character 'x' after top-level value`,
+		`01`:                                  `1:1: invalid number "01"`,
+		`{"a":[1,{"b":tru}]}`:                 `1:14: a[1].b: unexpected character 't', expected value`,
+		`{"a":"x`:                             `1:6: a: unterminated string`,
+		`"\q"`:                                `1:2: invalid escape sequence \q`,
+		`{"a":1} 2`:                           `1:9: unexpected character '2' after top-level value`,
+		"{\n  \"a\": {\n    \"b\": -\n  }\n}": `3:11: a.b: unexpected character '\n', expected value`,
+		``:                                    `1:1: unexpected end of input, expected value`,
+	}
+	for doc, expectedErr := range invalid {
+		_, err := parseJSON([]byte(doc), false)
+		assert.EqualError(t, err, expectedErr, doc)
+	}
+
+	_, err := parseJSON([]byte(strings.Repeat(`{"a":`, maxJSONDepth+1)), false)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exceeded max nesting depth of 10000")
+	}
+}
+
+func TestParseJSONRelaxed(t *testing.T) {
+	val, err := parseJSON([]byte(`
+// line comment
+{
+	/* block
+	   comment */
+	unquoted: 'single "quoted"',
+	$id_1: 0x1F,
+	"neg": -0XA,
+	lead: .5,
+	trail: 5.,
+	plus: +1,
+	inf: -Infinity,
+	list: [1, 2, 3,],
+	esc: 'it\'s \x41 multi\
+line',
+	"nested": {a: null,},
+}
+`), true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{
+			"unquoted": `single "quoted"`,
+			"$id_1":    float64(31),
+			"neg":      float64(-10),
+			"lead":     0.5,
+			"trail":    float64(5),
+			"plus":     float64(1),
+			"inf":      math.Inf(-1),
+			"list":     []interface{}{float64(1), float64(2), float64(3)},
+			"esc":      "it's A multiline",
+			"nested":   map[string]interface{}{"a": nil},
+		}, val)
+	}
+
+	val, err = parseJSON([]byte(`NaN`), true)
+	if assert.NoError(t, err) {
+		assert.True(t, math.IsNaN(val.(float64)))
+	}
+
+	invalid := map[string]string{
+		`{a:1,,}`:                `1:6: unexpected character ',', expected object key`,
+		`[1,,]`:                  `1:4: [1]: unexpected character ',', expected value`,
+		"{\n  a: /* open\n}":     `2:6: unterminated comment`,
+		"{\n  a: 0xZ,\n}":        `2:6: a: invalid number "0x"`,
+		"{\n  'a': 1\n  b: 2\n}": `3:3: unexpected character 'b', expected ',' or '}'`,
+	}
+	for doc, expectedErr := range invalid {
+		_, err := parseJSON([]byte(doc), true)
+		assert.EqualError(t, err, expectedErr, doc)
+	}
+}
+
+func TestLocateJSON(t *testing.T) {
+	data := []byte("{\n\t\"a\": [1, {\"b\": \"ä\", \"c\": 2}],\n\t\"d\": {\"e\": 1},\n\t\"d\": {\"e\": 2}\n}")
+	testCases := map[string]jsonPos{
+		"":       {1, 1},
+		"a":      {2, 7},
+		"a[1]":   {2, 11},
+		"a[1].c": {2, 27},
+		"d.e":    {4, 13},
+	}
+	for path, expected := range testCases {
+		prefix := newPathPrefix("")
+		for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '.' || r == '[' }) {
+			if index, err := strconv.Atoi(strings.TrimSuffix(part, "]")); err == nil {
+				prefix = prefix.Index(index)
+			} else {
+				prefix = prefix.Field(part)
+			}
+		}
+		pos, ok := locateJSON(data, false, prefix)
+		if assert.True(t, ok, path) {
+			assert.Equal(t, expected, pos, path)
+		}
+	}
+
+	for _, path := range []pathPrefix{newPathPrefix("x"), newPathPrefix("a").Index(2), newPathPrefix("a").Index(0).Field("b")} {
+		_, ok := locateJSON(data, false, path)
+		assert.False(t, ok, path.String())
+	}
+}
+
+func TestFromJSONRelaxed(t *testing.T) {
+	var conf IncludeTest
+	data := []byte(`{
+		// application name
+		Name: 'app',
+		DB: {Host: "db", Port: 0x1538,},
+	}`)
+	assert.Error(t, FromJSON(data, &conf))
+	if assert.NoError(t, FromJSON(data, &conf, WithRelaxedJSON())) {
+		assert.Equal(t, "app", conf.Name)
+		assert.Equal(t, "db", conf.DB.Host)
+		assert.Equal(t, 5432, conf.DB.Port)
+	}
+
+	err := FromJSON([]byte("{\n\tDB: {Port: 5432,, },\n}"), &conf, WithRelaxedJSON())
+	assert.EqualError(t, err, "2:18: DB: unexpected character ',', expected object key")
+}
+
+func TestFromFileRelaxed(t *testing.T) {
+	withTempFiles(t, map[string]string{
+		"config.jsonc":      "{\n\t// comment\n\t\"Name\": \"app\",\n\t\"$include\": \"db.json5\",\n}",
+		"db.json5":          `{DB: {Host: 'db', Port: 5432}}`,
+		"invalid.jsonc":     "{\n\t\"Name\": \"app\",\n\t\"DB\": {\"Port\": 54 32},\n}",
+		"conf.d/10-a.json":  `{"Name":"app"}`,
+		"conf.d/20-b.jsonc": `{"Features": ["a",], /* comment */}`,
+		"conf.d/30-c.json5": `{DB: {Port: 0x1538}}`,
+	}, func(dir string) {
+		var conf IncludeTest
+		if assert.NoError(t, FromFile(filepath.Join(dir, "config.jsonc"), &conf)) {
+			assert.Equal(t, "app", conf.Name)
+			assert.Equal(t, "db", conf.DB.Host)
+			assert.Equal(t, 5432, conf.DB.Port)
+		}
+
+		err := FromFile(filepath.Join(dir, "invalid.jsonc"), &conf)
+		assert.EqualError(t, err, filepath.Join(dir, "invalid.jsonc")+":3:20: DB: unexpected character '3', expected ',' or '}'")
+
+		conf = IncludeTest{}
+		if assert.NoError(t, FromConfigDir(filepath.Join(dir, "conf.d"), &conf)) {
+			assert.Equal(t, "app", conf.Name)
+			assert.Equal(t, []string{"a"}, conf.Features)
+			assert.Equal(t, 5432, conf.DB.Port)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MergeJSON combines multiple JSON documents according to RFC 7386 JSON Merge Patch. Each document, including the first one, is applied as patch to the result of all previous documents, starting with an empty document.
+//
+// Objects are merged key by key and null values delete the corresponding key. All other values, including arrays, replace the previous value. Numbers are kept as written, so large integers are not rounded.
+func MergeJSON(docs ...[]byte) ([]byte, error) {
+	var result interface{}
+	for i, doc := range docs {
+		patch, err := decodeMergePatch(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %s", i, err.Error())
+		}
+		result = mergeJSON(result, patch, SliceMergeReplace)
+	}
+	return json.Marshal(result)
+}
+
+// decodeMergePatch decodes a single JSON document with numbers as json.Number.
+func decodeMergePatch(doc []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var patch interface{}
+	if err := dec.Decode(&patch); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return patch, nil
+}
+
+// mergeJSON deep-merges the decoded JSON document overlay into base following RFC 7386. Slices are merged according to mode.
+func mergeJSON(base, overlay interface{}, mode SliceMergeMode) interface{} {
+	switch overlayVal := overlay.(type) {
+	case map[string]interface{}:
+		baseVal, _ := base.(map[string]interface{})
+		merged := make(map[string]interface{}, len(baseVal)+len(overlayVal))
+		for key, val := range baseVal {
+			merged[key] = val
+		}
+		for key, val := range overlayVal {
+			if val == nil {
+				// null removes keys
+				delete(merged, key)
+			} else {
+				merged[key] = mergeJSON(merged[key], val, mode)
+			}
+		}
+		return merged
+
+	case []interface{}:
+		baseVal, ok := base.([]interface{})
+		if !ok || mode != SliceMergeAppend {
+			return overlay
+		}
+		merged := make([]interface{}, 0, len(baseVal)+len(overlayVal))
+		merged = append(merged, baseVal...)
+		return append(merged, overlayVal...)
+
+	default:
+		return overlay
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeJSON(t *testing.T) {
+	merged, err := MergeJSON(
+		[]byte(`{"a":1,"b":{"c":"x","d":[1,2]},"e":[1],"f":"keep","g":"remove"}`),
+		[]byte(`{"a":2,"b":{"d":[3],"h":true},"e":{"i":null,"j":1},"g":null}`),
+		[]byte(`{"b":{"c":null}}`),
+	)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"a":2,"b":{"d":[3],"h":true},"e":{"j":1},"f":"keep"}`, string(merged))
+	}
+
+	merged, err = MergeJSON([]byte(`{"a":1}`), []byte(`[1,2]`))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `[1,2]`, string(merged))
+	}
+
+	merged, err = MergeJSON()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "null", string(merged))
+	}
+
+	// the first document is applied to an empty document as well
+	merged, err = MergeJSON([]byte(`{"a":null,"b":{"c":null,"d":1}}`))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"b":{"d":1}}`, string(merged))
+	}
+
+	merged, err = MergeJSON([]byte(`{"a":12345678901234567891,"b":1.50}`), []byte(`{"c":-0.1e3}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"a":12345678901234567891,"b":1.50,"c":-0.1e3}`, string(merged))
+	}
+
+	_, err = MergeJSON([]byte(`{}`), []byte(`{`))
+	assert.EqualError(t, err, "document 1: unexpected EOF")
+	_, err = MergeJSON([]byte(`{} {}`))
+	assert.EqualError(t, err, "document 0: unexpected data after top-level value")
+}
+
+// TestMergeJSONRFC7386 checks the examples from appendix A of RFC 7386, which apply a patch to a given target.
+func TestMergeJSONRFC7386(t *testing.T) {
+	testCases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, testCase := range testCases {
+		var target, patch interface{}
+		require.NoError(t, json.Unmarshal([]byte(testCase[0]), &target))
+		require.NoError(t, json.Unmarshal([]byte(testCase[1]), &patch))
+		merged, err := json.Marshal(mergeJSON(target, patch, SliceMergeReplace))
+		if assert.NoError(t, err, testCase[1]) {
+			assert.JSONEq(t, testCase[2], string(merged), testCase[1])
+		}
+	}
+}
+
+func TestMergeJSONSliceAppend(t *testing.T) {
+	var base, overlay interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":[1,2],"b":{"c":[1]}}`), &base))
+	require.NoError(t, json.Unmarshal([]byte(`{"a":[3],"b":{"c":"x"}}`), &overlay))
+
+	merged, err := json.Marshal(mergeJSON(base, overlay, SliceMergeAppend))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"a":[1,2,3],"b":{"c":"x"}}`, string(merged))
+	}
+}
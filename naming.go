@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PathPart is an element of the path to a configuration value.
+type PathPart struct {
+	// Name is the visible name of a struct field or prefix. It is empty for indices.
+	Name string
+	// Index is the position in a slice or array if IsIndex is set.
+	Index   int
+	IsIndex bool
+}
+
+// EnvNaming assembles the name of an environment variable from the path of a configuration value.
+type EnvNaming func(parts []PathPart) string
+
+var (
+	// DefaultEnvNaming joins upper case names with "_", e.g. MAIN_MAXIDLECONNS.
+	DefaultEnvNaming EnvNaming = EnvNamingStyle{}.Name
+	// SnakeCaseEnvNaming splits camelCase names into words, e.g. MAIN_MAX_IDLE_CONNS.
+	SnakeCaseEnvNaming EnvNaming = EnvNamingStyle{SplitCamelCase: true}.Name
+)
+
+// EnvNamingStyle describes a common naming strategy for environment variables. Pass its Name method to WithEnvNaming.
+type EnvNamingStyle struct {
+	// Separator is inserted between path parts. Defaults to "_", use "__" for nested configuration as in .NET.
+	Separator string
+	// SplitCamelCase separates words in camelCase names with "_", so MaxIdleConns becomes MAX_IDLE_CONNS.
+	SplitCamelCase bool
+	// KeepCase retains the case of names instead of converting them to upper case.
+	KeepCase bool
+}
+
+// Name returns the environment variable name for the given path.
+func (s EnvNamingStyle) Name(parts []PathPart) string {
+	separator := s.Separator
+	if len(separator) == 0 {
+		separator = "_"
+	}
+
+	var sb strings.Builder
+	for _, part := range parts {
+		name := part.Name
+		if part.IsIndex {
+			name = strconv.Itoa(part.Index)
+		} else {
+			if s.SplitCamelCase {
+				name = strings.Join(splitCamelCase(name), "_")
+			}
+			if !s.KeepCase {
+				name = strings.ToUpper(name)
+			}
+		}
+
+		if len(name) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(name)
+	}
+	return sb.String()
+}
+
+// splitCamelCase returns the words of a camelCase name. Consecutive upper case letters are treated as acronym, so HTTPServer results in HTTP and Server.
+func splitCamelCase(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		// lowerUpper or ACRONYMWord boundaries
+		if unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
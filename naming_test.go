@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCamelCase(t *testing.T) {
+	assert.Equal(t, []string{"Max", "Idle", "Conns"}, splitCamelCase("MaxIdleConns"))
+	assert.Equal(t, []string{"max", "Idle"}, splitCamelCase("maxIdle"))
+	assert.Equal(t, []string{"HTTP", "Server"}, splitCamelCase("HTTPServer"))
+	assert.Equal(t, []string{"User", "ID"}, splitCamelCase("UserID"))
+	assert.Equal(t, []string{"Port2", "Name"}, splitCamelCase("Port2Name"))
+	assert.Equal(t, []string{"MAIN"}, splitCamelCase("MAIN"))
+	assert.Equal(t, []string{"OLD_NAME"}, splitCamelCase("OLD_NAME"))
+	assert.Equal(t, []string{}, splitCamelCase(""))
+}
+
+func TestEnvNamingStyle(t *testing.T) {
+	path := newPathPrefix("Main").Field("DB").Field2("Pool", "MaxIdleConns").Index(2).Parts()
+	assert.Equal(t, "MAIN_DB_MAXIDLECONNS_2", DefaultEnvNaming(path))
+	assert.Equal(t, "MAIN_DB_MAX_IDLE_CONNS_2", SnakeCaseEnvNaming(path))
+	assert.Equal(t, "MAIN__DB__MAX_IDLE_CONNS__2", EnvNamingStyle{Separator: "__", SplitCamelCase: true}.Name(path))
+	assert.Equal(t, "Main.DB.MaxIdleConns.2", EnvNamingStyle{Separator: ".", KeepCase: true}.Name(path))
+	assert.Equal(t, "", DefaultEnvNaming(newPathPrefix("").Parts()))
+}
+
+type NamingTestConfig struct {
+	MaxIdleConns int
+	HTTPServer   struct {
+		ListenAddr string
+	}
+	Hosts []string
+}
+
+func TestEnvNaming(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["MAIN__MAX_IDLE_CONNS"] = "5"
+		env["MAIN__HTTP_SERVER__LISTEN_ADDR"] = ":8080"
+		env["MAIN__HOSTS__0"] = "foo"
+		env["MAIN__HOSTS__1"] = "bar"
+
+		var conf NamingTestConfig
+		naming := EnvNamingStyle{Separator: "__", SplitCamelCase: true}.Name
+		if assert.NoError(t, FromEnvironment("Main", &conf, WithEnvNaming(naming), WithAutoSliceLength(), WithStrictMode())) {
+			assert.Equal(t, 5, conf.MaxIdleConns)
+			assert.Equal(t, ":8080", conf.HTTPServer.ListenAddr)
+			assert.Equal(t, []string{"foo", "bar"}, conf.Hosts)
+		}
+	})
+}
+
+func TestEnvNamingCustom(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["main.maxidleconns"] = "5"
+
+		var conf NamingTestConfig
+		naming := func(parts []PathPart) string {
+			return strings.ToLower(EnvNamingStyle{Separator: "."}.Name(parts))
+		}
+		if assert.NoError(t, FromEnvironment("Main", &conf, WithEnvNaming(naming))) {
+			assert.Equal(t, 5, conf.MaxIdleConns)
+		}
+	})
+}
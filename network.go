@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// HostPort is a host name or ip address with a port like localhost:8080, [::1]:8080 or :8080, e.g. for listen addresses. The host may be empty.
+type HostPort struct {
+	Host string
+	Port int
+}
+
+func (hp HostPort) String() string {
+	if hp == (HostPort{}) {
+		return ""
+	}
+	return net.JoinHostPort(hp.Host, strconv.Itoa(hp.Port))
+}
+
+// isNetworkType returns true for the built-in network types that are parsed from a single string.
+func isNetworkType(t reflect.Type) bool {
+	switch t {
+	case typeURL, typeIP, typeIPNet, typeHardwareAddr, typeAddrPort, typeHostPort:
+		return true
+	}
+	return false
+}
+
+// parseNetworkValue parses strVal as value of the network type t. An empty string results in the zero value.
+func parseNetworkValue(t reflect.Type, strVal string) (interface{}, error) {
+	if len(strVal) == 0 {
+		return reflect.Zero(t).Interface(), nil
+	}
+
+	switch t {
+	case typeURL:
+		u, err := url.Parse(strVal)
+		if err != nil {
+			return nil, urlParseError(strVal)
+		}
+		if !u.IsAbs() {
+			return nil, fmt.Errorf("cannot parse url from %q: scheme missing", redactURL(strVal))
+		}
+		return *u, nil
+
+	case typeIP:
+		ip := net.ParseIP(strVal)
+		if ip == nil {
+			return nil, fmt.Errorf("cannot parse ip address from %q", strVal)
+		}
+		return ip, nil
+
+	case typeIPNet:
+		_, ipNet, err := net.ParseCIDR(strVal)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse network from %q: expected CIDR notation like 10.0.0.0/8", strVal)
+		}
+		return *ipNet, nil
+
+	case typeHardwareAddr:
+		mac, err := net.ParseMAC(strVal)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse hardware address from %q", strVal)
+		}
+		return mac, nil
+
+	case typeAddrPort:
+		addrPort, err := netip.ParseAddrPort(strVal)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse ip address and port from %q", strVal)
+		}
+		return addrPort, nil
+
+	case typeHostPort:
+		host, portStr, err := net.SplitHostPort(strVal)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse host and port from %q", strVal)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port < 0 || port > 65535 {
+			return nil, fmt.Errorf("cannot parse host and port from %q: invalid port %q", strVal, portStr)
+		}
+		return HostPort{host, port}, nil
+
+	default:
+		panic(fmt.Sprintf("unsupported network type %s", t))
+	}
+}
+
+// formatNetworkValue returns the string representation of a network value. Passwords in urls are redacted.
+func formatNetworkValue(v reflect.Value) string {
+	switch val := v.Interface().(type) {
+	case url.URL:
+		return val.Redacted()
+	case net.IPNet:
+		if val.IP == nil {
+			return ""
+		}
+		return val.String()
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// urlParseError returns the error for the invalid url strVal without revealing its password.
+func urlParseError(strVal string) error {
+	// errors of url.Parse repeat the input and may contain parts of the password, so only the redacted url is parsed for the message
+	redacted := redactURL(strVal)
+	_, err := url.Parse(redacted)
+	if err == nil {
+		return fmt.Errorf("cannot parse url from %q: invalid user info", redacted)
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	return fmt.Errorf("cannot parse url from %q: %s", redacted, err.Error())
+}
+
+// redactURL hides the password of a url that is only partially valid or lacks the scheme.
+func redactURL(strVal string) string {
+	if u, err := url.Parse(strVal); err == nil && (u.User != nil || len(u.Host) > 0) {
+		return u.Redacted()
+	}
+
+	// other values are masked from the start of the user info to the last @, as passwords may contain any character
+	authority := strings.Index(strVal, "//")
+	if authority < 0 {
+		// values like user:secret@host are parsed as opaque url with the user name as scheme
+		authority = 0
+	} else {
+		authority += 2
+	}
+	at := strings.LastIndex(strVal[authority:], "@")
+	if at < 0 {
+		return strVal
+	}
+	colon := strings.Index(strVal[authority:authority+at], ":")
+	if colon < 0 {
+		return strVal
+	}
+	return strVal[:authority+colon+1] + "xxxxx" + strVal[authority+at:]
+}
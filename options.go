@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"log"
+)
+
+// Option customizes the behavior of the configuration loaders.
+type Option func(*options)
+
+type options struct {
+	AutoSliceLength bool
+	Strict          bool
+	WarningHandler  func(msg string)
+	EnvNaming       EnvNaming
+	Profile         string
+	ProfileEnv      string
+	SliceMerge      SliceMergeMode
+	OptionalDir     bool
+	RelaxedJSON     bool
+	NoValidation    bool
+}
+
+// SliceMergeMode determines how slices of a profile overlay are merged into the base configuration.
+type SliceMergeMode int
+
+const (
+	// SliceMergeReplace replaces slices of the base configuration by slices of the overlay.
+	SliceMergeReplace SliceMergeMode = iota
+	// SliceMergeAppend appends the items of overlay slices to the base slices.
+	SliceMergeAppend
+)
+
+// WithAutoSliceLength determines the length of slices from indexed environment variables like {PREFIX}_0 or {PREFIX}_0_{FIELD} when {PREFIX}_NUM is not set.
+//
+// Gaps in the indices are reported as error, as well as a {PREFIX}_NUM value that does not match the indices present.
+func WithAutoSliceLength() Option {
+	return func(o *options) {
+		o.AutoSliceLength = true
+	}
+}
+
+// WithStrictMode rejects configuration values that are not consumed by any field.
+//
+// FromJSON fails on object keys that match no field. FromEnvironment fails on variables starting with the non-empty prefix that have not been read, and suggests similar known names.
+func WithStrictMode() Option {
+	return func(o *options) {
+		o.Strict = true
+	}
+}
+
+// WithWarningHandler sets the function to receive warnings like the use of deprecated names. Warnings are written to the standard logger by default.
+func WithWarningHandler(handler func(msg string)) Option {
+	return func(o *options) {
+		o.WarningHandler = handler
+	}
+}
+
+// WithEnvNaming sets the strategy to assemble environment variable names from the configuration hierarchy. DefaultEnvNaming is used by default.
+func WithEnvNaming(naming EnvNaming) Option {
+	return func(o *options) {
+		o.EnvNaming = naming
+	}
+}
+
+// WithProfile activates a configuration profile like "dev" or "prod". FromFile loads the overlay file config.<profile>.json on top of config.json if present, and default.<profile> tag values take precedence over default values.
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.Profile = profile
+	}
+}
+
+// WithProfileEnv reads the profile from the given environment variable, e.g. MAIN_PROFILE, if it is not set by WithProfile.
+func WithProfileEnv(key string) Option {
+	return func(o *options) {
+		o.ProfileEnv = key
+	}
+}
+
+// WithSliceMerge sets how slices of profile overlays are merged. Slices are replaced by default.
+func WithSliceMerge(mode SliceMergeMode) Option {
+	return func(o *options) {
+		o.SliceMerge = mode
+	}
+}
+
+// WithOptionalDir lets FromConfigDir accept missing or empty directories.
+func WithOptionalDir() Option {
+	return func(o *options) {
+		o.OptionalDir = true
+	}
+}
+
+// WithRelaxedJSON accepts JSON5 input with comments, trailing commas, unquoted keys, single quoted strings and hexadecimal numbers. Files with the extension .jsonc or .json5 are always parsed in relaxed mode.
+func WithRelaxedJSON() Option {
+	return func(o *options) {
+		o.RelaxedJSON = true
+	}
+}
+
+// WithoutValidation skips the validation at the end of a loader. Use it for all but the last of multiple loaders, like FromFile followed by FromEnvironment, and call Validate after the last one to check constraints on the combined configuration.
+func WithoutValidation() Option {
+	return func(o *options) {
+		o.NoValidation = true
+	}
+}
+
+// loadContext holds the options and state of a single load operation.
+type loadContext struct {
+	Options options
+	// EnvKeys contains all environment variable names that have been looked up and whether they are set.
+	EnvKeys map[string]bool
+	// AssignCount is the number of values that have been assigned from configuration so far.
+	AssignCount int
+	// Resolved contains the values of all loaded paths in lower case for variable references. It is nil unless TrackReferences finds possible references.
+	Resolved map[string]string
+	// Documents contains the loaded JSON documents to find the positions of invalid values.
+	Documents []jsonDocument
+	// Patch denotes partial updates that neither assign default values nor call SetDefaults.
+	Patch bool
+	// NoHooks denotes ApplyDefaults, which assigns default values without calling SetDefaults and AfterLoad.
+	NoHooks bool
+}
+
+func newLoadContext(opts []Option) *loadContext {
+	ctx := &loadContext{
+		Options: options{
+			WarningHandler: func(msg string) {
+				log.Println("config:", msg)
+			},
+			EnvNaming: DefaultEnvNaming,
+		},
+		EnvKeys: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(&ctx.Options)
+	}
+	if len(ctx.Options.Profile) == 0 && len(ctx.Options.ProfileEnv) > 0 {
+		ctx.Options.Profile, _ = ctx.LookupEnv(ctx.Options.ProfileEnv)
+	}
+	return ctx
+}
+
+// LookupEnv returns the value of an environment variable and remembers the key as known.
+func (ctx *loadContext) LookupEnv(key string) (string, bool) {
+	val, ok := lookupEnv(key)
+	ctx.EnvKeys[key] = ok
+	return val, ok
+}
+
+// Warn passes a formatted warning to the warning handler.
+func (ctx *loadContext) Warn(format string, args ...interface{}) {
+	if ctx.Options.WarningHandler != nil {
+		ctx.Options.WarningHandler(fmt.Sprintf(format, args...))
+	}
+}
+
+// EnvName returns the environment variable name for prefix according to the naming strategy.
+func (ctx *loadContext) EnvName(prefix pathPrefix) string {
+	return ctx.Options.EnvNaming(prefix.Parts())
+}
+
+// Default returns the default value of tag for the active profile.
+func (ctx *loadContext) Default(tag *tag) (string, bool) {
+	if tag == nil {
+		return "", false
+	}
+	if val, ok := tag.ProfileDefaults[ctx.Options.Profile]; ok && len(ctx.Options.Profile) > 0 {
+		return val, true
+	}
+	return tag.Default, tag.HasDefault
+}
@@ -106,12 +106,54 @@ func ToList(prefix string, conf interface{}) []NamedValue {
 }
 
 func sprint(lines *[]printLine, prefix pathPrefix, obj *object, mode printMode, tag *tag) {
+	if enum, ok := lookupEnum(obj.t); ok && obj.IsReadable() {
+		if name, ok := enum.Name(obj.Interface()); ok {
+			if mode == printModeNonZero && obj.v.IsZero() {
+				return
+			}
+			*lines = append(*lines, printLine{prefix.String(), name, mode, tag})
+			return
+		}
+	}
+
+	if isNetworkType(obj.t) {
+		*lines = append(*lines, printLine{prefix.String(), formatNetworkValue(obj.v), mode, tag})
+		return
+	}
+	if obj.Kind() == reflect.Ptr && isNetworkType(obj.t.Elem()) {
+		// *url.URL implements fmt.Stringer, but the password must be redacted
+		if !obj.IsNil() {
+			sprint(lines, prefix, obj.Elem(), mode, tag)
+		}
+		return
+	}
+
+	if isByteSlice(obj.t) {
+		// byte slices often contain key material and are not printed unless explicitly requested
+		switch mode {
+		case printModeDefault:
+			mode = printModeLen
+		case printModeNonZero:
+			if obj.Len() == 0 {
+				return
+			}
+			mode = printModeLen
+		}
+		*lines = append(*lines, printLine{prefix.String(), obj.Interface(), mode, tag})
+		return
+	}
+
 	if mode == printModeLen {
 		// do not print full hierarchy, only number of elements:
 		*lines = append(*lines, printLine{prefix.String(), obj.Interface(), mode, tag})
 		return
 	}
 
+	if tag != nil && tag.Unit == unitBytes && obj.Kind() == reflect.Int {
+		*lines = append(*lines, printLine{prefix.String(), ByteSize(obj.v.Int()), mode, tag})
+		return
+	}
+
 	if stringer, ok := obj.Interface().(fmt.Stringer); ok {
 		*lines = append(*lines, printLine{prefix.String(), stringer, mode, tag})
 		return
@@ -137,14 +179,14 @@ func sprint(lines *[]printLine, prefix pathPrefix, obj *object, mode printMode,
 }
 
 func sprintStruct(lines *[]printLine, prefix pathPrefix, obj *object, mode printMode) {
-	obj.IterateStruct(func(obj *object, tag tag) error {
+	obj.IterateStruct(func(obj *object, tag *tag) error {
 		if obj.IsReadable() {
 			if tag.PrintMode != printModeNone {
 				newMode := mode
 				if tag.PrintMode != printModeDefault {
 					newMode = tag.PrintMode
 				}
-				sprint(lines, prefix.Field(tag.PrintName), obj, newMode, &tag)
+				sprint(lines, prefix.Field(tag.PrintName), obj, newMode, tag)
 			}
 		}
 		return nil
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"net"
+	"net/url"
 	"testing"
 	"time"
 
@@ -134,3 +136,49 @@ func TestToStringTime(t *testing.T) {
 	}
 	assert.Equal(t, "Stuff.Time:     2020-05-05 13:49:44 +0000 UTC\nStuff.Duration: 2h15m0s", ToString("Stuff", conf))
 }
+
+type PrintTestEmbedded struct {
+	PrintTestSimple
+	Port   int
+	Nested PrintTestSimple `config:"inline"`
+}
+
+func TestToStringEmbedded(t *testing.T) {
+	conf := PrintTestEmbedded{PrintTestSimple{"foobar", 42, true}, 8080, PrintTestSimple{"bar", 1337, false}}
+	assert.Equal(t, "Stuff.Str:     foobar\nStuff.Number:  42\nStuff.Boolean: true\nStuff.Port:    8080\nStuff.Str:     bar\nStuff.Number:  1337\nStuff.Boolean: false", ToString("Stuff", conf))
+}
+
+type PrintTestBytes struct {
+	Key     []byte
+	Empty   []byte `config:"print:[nonzero]"`
+	Visible []byte `config:"print:[mask]"`
+}
+
+func TestToStringBytes(t *testing.T) {
+	conf := PrintTestBytes{[]byte("secret"), nil, []byte("x")}
+	assert.Equal(t, "Stuff.Key:     6\nStuff.Visible: ******", ToString("Stuff", conf))
+}
+
+type PrintTestNetwork struct {
+	URL     *url.URL
+	IP      net.IP
+	Network net.IPNet
+}
+
+func TestToStringNetwork(t *testing.T) {
+	u, _ := url.Parse("postgres://user:secret@db:5432/app")
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	conf := PrintTestNetwork{u, net.IPv4(10, 0, 0, 1), *network}
+	assert.Equal(t, "Stuff.URL:     postgres://user:xxxxx@db:5432/app\nStuff.IP:      10.0.0.1\nStuff.Network: 10.0.0.0/8", ToString("Stuff", conf))
+}
+
+type PrintTestEnum struct {
+	Level   EnumTestLevel
+	Unknown EnumTestLevel
+	Zero    EnumTestLevel `config:"print:[nonzero]"`
+}
+
+func TestToStringEnum(t *testing.T) {
+	conf := PrintTestEnum{EnumTestWarn, EnumTestLevel(42), EnumTestDebug}
+	assert.Equal(t, "Stuff.Level:   warn\nStuff.Unknown: 42", ToString("Stuff", conf))
+}
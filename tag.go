@@ -3,21 +3,154 @@ package config
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 )
 
 type tag struct {
-	FieldName  string
-	Required   bool
-	PrintMode  printMode
-	PrintName  string
-	EnvName    string
-	JSONName   string
+	FieldName string
+	Required  bool
+	PrintMode printMode
+	PrintName string
+	EnvName   string
+	JSONName  string
+	NoJSON    bool
+	// Aliases are alternative names for environment variables and JSON keys.
+	Aliases []fieldAlias
+	// Inline denotes struct fields whose fields are treated as fields of the parent struct.
+	Inline     bool
 	Default    string
 	HasDefault bool
+	// ProfileDefaults contains the default values of default.<profile> options by profile.
+	ProfileDefaults map[string]string
+	// Layout and TimeZone control how time.Time values are parsed.
+	Layout   string
+	TimeZone string
+	// Calendar denotes durations with years and months of average length in the gregorian calendar.
+	Calendar bool
+	// Unit denotes the format of numeric values, e.g. unitBytes to parse them like ByteSize.
+	Unit string
+	// Encoding denotes the format of byte slices, e.g. encodingHex. Defaults to base64.
+	Encoding string
+	// Constraints are checked by Validate after loading.
+	Constraints []constraint
+}
+
+const (
+	unitBytes = "bytes"
+
+	profileDefaultPrefix = "default."
+
+	encodingBase64 = "base64"
+	encodingHex    = "hex"
+	encodingRaw    = "raw"
+	encodingPEM    = "pem"
+)
+
+type constraint struct {
+	Name string
+	Arg  string
+}
+
+var knownOptions = []string{
+	"required", "name", "env", "print", "default", "unit", "encoding", "calendar", "layout", "tz", "inline", "noinline", "alias", "deprecated",
+	"nonempty", "url", "hostport", "min", "max", "len", "oneof", "pattern",
 }
 
 func getTag(field reflect.StructField) tag {
+	tag, err := parseTag(field)
+	if err != nil {
+		panic(fmt.Sprintf("field %s: %s", field.Name, err.Error()))
+	}
+	return tag
+}
+
+// structField is a field of a struct type with its parsed config tag.
+type structField struct {
+	Index int
+	Type  reflect.Type
+	Tag   tag
+}
+
+// structFieldCache maps struct types to their []structField.
+var structFieldCache sync.Map
+
+// structFields returns all fields of the struct type t with their parsed tags. Tags are only parsed once per type, as loaders visit the fields of every struct value. The returned tags must not be modified.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	fields := make([]structField, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		fields[i] = structField{i, field.Type, getTag(field)}
+	}
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+// typeInfo describes which load steps apply to values of a type, including all nested types.
+type typeInfo struct {
+	// Validated denotes types with constraints or Validator implementations.
+	Validated bool
+	// References denotes types with default values that contain variable references.
+	References bool
+}
+
+// typeInfoCache maps types to their typeInfo.
+var typeInfoCache sync.Map
+
+var typeValidator = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// getTypeInfo returns the typeInfo of t, which is only collected once per type.
+func getTypeInfo(t reflect.Type) typeInfo {
+	if info, ok := typeInfoCache.Load(t); ok {
+		return info.(typeInfo)
+	}
+
+	var info typeInfo
+	info.collect(t, make(map[reflect.Type]bool))
+	typeInfoCache.Store(t, info)
+	return info
+}
+
+func (info *typeInfo) collect(t reflect.Type, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	if t.Implements(typeValidator) || reflect.PtrTo(t).Implements(typeValidator) {
+		info.Validated = true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		info.collect(t.Elem(), visited)
+
+	case reflect.Struct:
+		fields := structFields(t)
+		for i := range fields {
+			tag := &fields[i].Tag
+			if len(tag.Constraints) > 0 {
+				info.Validated = true
+			}
+			if strings.Contains(tag.Default, "${") {
+				info.References = true
+			}
+			for _, val := range tag.ProfileDefaults {
+				if strings.Contains(val, "${") {
+					info.References = true
+				}
+			}
+			info.collect(fields[i].Type, visited)
+		}
+	}
+}
+
+func parseTag(field reflect.StructField) (tag, error) {
 	tag := tag{
 		FieldName: field.Name,
 		Required:  false,
@@ -25,31 +158,67 @@ func getTag(field reflect.StructField) tag {
 		PrintName: field.Name,
 		EnvName:   field.Name,
 		JSONName:  field.Name,
+		// embedded structs are flattened by default like in encoding/json, unless the json tag gives them a name
+		Inline: field.Anonymous && isInlineType(field.Type) && !hasJSONName(field),
 	}
 
 	tagStr := field.Tag.Get("config")
 	if len(tagStr) > 0 {
+		tokens, err := tokenizeTag(tagStr)
+		if err != nil {
+			return tag, err
+		}
+
 		options := make(map[string][]string)
-		for _, val := range strings.Split(tagStr, ",") {
-			parts := strings.Split(val, ":")
-			opt := parts[0]
-			//TODO deny unknown config options
-			if _, ok := options[opt]; ok {
-				panic(fmt.Sprintf("config option %q specified multiple times", opt))
+		// columns of the options are needed for errors about their arguments
+		columns := make(map[string]int)
+		for _, token := range tokens {
+			if err := checkOptionName(token.Name); err != nil {
+				return tag, fmt.Errorf("column %d: %s", token.Column, err.Error())
+			}
+			if _, ok := options[token.Name]; ok {
+				return tag, fmt.Errorf("column %d: config option %q specified multiple times", token.Column, token.Name)
 			}
-			options[opt] = parts[1:]
+			options[token.Name] = token.Args
+			columns[token.Name] = token.Column
 		}
 
 		if args, ok := options["required"]; ok {
 			if len(args) != 0 {
-				panic("config option \"required\" does not support any arguments")
+				return tag, fmt.Errorf("column %d: config option \"required\" does not support any arguments", columns["required"])
 			}
 			tag.Required = true
 		}
 
+		_, inline := options["inline"]
+		_, noInline := options["noinline"]
+		if inline && noInline {
+			// report the option that comes last
+			column := columns["inline"]
+			if columns["noinline"] > column {
+				column = columns["noinline"]
+			}
+			return tag, fmt.Errorf("column %d: config options \"inline\" and \"noinline\" are mutually exclusive", column)
+		}
+		if inline {
+			if len(options["inline"]) != 0 {
+				return tag, fmt.Errorf("column %d: config option \"inline\" does not support any arguments", columns["inline"])
+			}
+			if !isInlineType(field.Type) {
+				return tag, fmt.Errorf("column %d: config option \"inline\" requires a struct or struct pointer field", columns["inline"])
+			}
+			tag.Inline = true
+		}
+		if noInline {
+			if len(options["noinline"]) != 0 {
+				return tag, fmt.Errorf("column %d: config option \"noinline\" does not support any arguments", columns["noinline"])
+			}
+			tag.Inline = false
+		}
+
 		if args, ok := options["name"]; ok {
 			if len(args) != 1 {
-				panic("config option \"name\" requires exactly one argument")
+				return tag, fmt.Errorf("column %d: config option \"name\" requires exactly one argument", columns["name"])
 			}
 			// needs to be evaluated before all other names to prevent overrides
 			tag.FieldName = args[0]
@@ -60,27 +229,222 @@ func getTag(field reflect.StructField) tag {
 
 		if args, ok := options["env"]; ok {
 			if len(args) != 1 {
-				panic("config option \"env\" requires exactly one argument")
+				return tag, fmt.Errorf("column %d: config option \"env\" requires exactly one argument", columns["env"])
 			}
 			tag.EnvName = args[0]
 		}
 
+		for _, name := range []string{"alias", "deprecated"} {
+			if args, ok := options[name]; ok {
+				if len(args) != 1 || len(args[0]) == 0 {
+					return tag, fmt.Errorf("column %d: config option %q requires exactly one argument", columns[name], name)
+				}
+				for _, alias := range strings.Split(args[0], "|") {
+					tag.Aliases = append(tag.Aliases, fieldAlias{alias, name == "deprecated"})
+				}
+			}
+		}
+
 		if args, ok := options["print"]; ok {
-			setPrintOptions(args, &tag)
+			if err := setPrintOptions(args, &tag); err != nil {
+				return tag, fmt.Errorf("column %d: %s", columns["print"], err.Error())
+			}
+		}
+
+		if args, ok := options["unit"]; ok {
+			if len(args) != 1 || args[0] != unitBytes {
+				return tag, fmt.Errorf("column %d: config option \"unit\" requires the argument %q", columns["unit"], unitBytes)
+			}
+			tag.Unit = args[0]
+		}
+
+		if args, ok := options["encoding"]; ok {
+			if len(args) != 1 {
+				return tag, fmt.Errorf("column %d: config option \"encoding\" requires exactly one argument", columns["encoding"])
+			}
+			switch args[0] {
+			case encodingBase64, encodingHex, encodingRaw, encodingPEM:
+				tag.Encoding = args[0]
+			default:
+				return tag, fmt.Errorf("column %d: unknown encoding %q", columns["encoding"], args[0])
+			}
+		}
+
+		if args, ok := options["layout"]; ok {
+			if len(args) == 0 || len(args[0]) == 0 {
+				return tag, fmt.Errorf("column %d: config option \"layout\" requires an argument", columns["layout"])
+			}
+			// layouts usually contain colons
+			tag.Layout = strings.Join(args, ":")
+		}
+
+		if args, ok := options["tz"]; ok {
+			if len(args) != 1 || len(args[0]) == 0 {
+				return tag, fmt.Errorf("column %d: config option \"tz\" requires exactly one argument", columns["tz"])
+			}
+			tag.TimeZone = args[0]
+		}
+
+		if args, ok := options["calendar"]; ok {
+			if len(args) != 0 {
+				return tag, fmt.Errorf("column %d: config option \"calendar\" does not support any arguments", columns["calendar"])
+			}
+			tag.Calendar = true
 		}
 
 		if args, ok := options["default"]; ok {
 			tag.Default = strings.Join(args, ":")
 			tag.HasDefault = true
 		}
+		for name, args := range options {
+			if profile := strings.TrimPrefix(name, profileDefaultPrefix); profile != name {
+				if tag.ProfileDefaults == nil {
+					tag.ProfileDefaults = make(map[string]string)
+				}
+				tag.ProfileDefaults[profile] = strings.Join(args, ":")
+			}
+		}
+
+		constraints, err := getConstraints(options, columns)
+		if err != nil {
+			return tag, err
+		}
+		tag.Constraints = constraints
 	}
 
-	return tag
+	// field name can be overwritten by json tag
+	if jsonTag := field.Tag.Get("json"); len(jsonTag) > 0 {
+		parts := strings.Split(jsonTag, ",")
+		if len(parts) == 1 && parts[0] == "-" {
+			// do not allow json input for this field
+			tag.NoJSON = true
+		} else if len(parts[0]) > 0 {
+			tag.JSONName = parts[0]
+		}
+	}
+
+	return tag, nil
+}
+
+// isInlineType returns true for structs and pointers to structs whose fields can be treated as fields of the parent struct.
+func isInlineType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isSingleValueType(t)
+}
+
+// isSingleValueType returns true for structs that are parsed from a single string like time.Time or url.URL.
+func isSingleValueType(t reflect.Type) bool {
+	return t == typeDateTime || isEnumType(t) || isNetworkType(t)
+}
+
+// hasJSONName returns true if the json tag of field specifies a key name.
+func hasJSONName(field reflect.StructField) bool {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	return len(name) > 0 && name != "-"
+}
+
+type tagToken struct {
+	Name   string
+	Args   []string
+	Column int
+}
+
+// tokenizeTag splits a config tag into options separated by "," with arguments separated by ":".
+//
+// Text enclosed in single quotes is taken literally, only \' is unescaped there. Outside of quotes, a backslash escapes the characters ",", ":", "'" and "\". Other backslashes are kept to allow regular expressions like \d.
+func tokenizeTag(str string) ([]tagToken, error) {
+	tokens := make([]tagToken, 0)
+	runes := []rune(str)
+
+	var parts []string
+	var sb strings.Builder
+	tokenColumn := 1
+	quoteColumn := 0
+
+	endToken := func(column int) error {
+		parts = append(parts, sb.String())
+		sb.Reset()
+		if len(parts[0]) == 0 {
+			return fmt.Errorf("column %d: missing config option name", tokenColumn)
+		}
+		tokens = append(tokens, tagToken{parts[0], parts[1:], tokenColumn})
+		parts = nil
+		tokenColumn = column + 1
+		return nil
+	}
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		column := i + 1
+
+		if quoteColumn > 0 {
+			switch {
+			case c == '\\' && i+1 < len(runes) && runes[i+1] == '\'':
+				i++
+				sb.WriteRune('\'')
+			case c == '\'':
+				quoteColumn = 0
+			default:
+				sb.WriteRune(c)
+			}
+			continue
+		}
+
+		switch c {
+		case '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("column %d: incomplete escape sequence", column)
+			}
+			switch runes[i+1] {
+			case ',', ':', '\'', '\\':
+				i++
+				sb.WriteRune(runes[i])
+			default:
+				sb.WriteRune(c)
+			}
+		case '\'':
+			quoteColumn = column
+		case ':':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		case ',':
+			if err := endToken(column); err != nil {
+				return nil, err
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+
+	if quoteColumn > 0 {
+		return nil, fmt.Errorf("column %d: unterminated quote", quoteColumn)
+	}
+	if err := endToken(len(runes)); err != nil {
+		return nil, err
+	}
+	return tokens, nil
 }
 
-func setPrintOptions(args []string, tag *tag) {
+func checkOptionName(opt string) error {
+	if strings.HasPrefix(opt, profileDefaultPrefix) && len(opt) > len(profileDefaultPrefix) {
+		return nil
+	}
+	for _, known := range knownOptions {
+		if opt == known {
+			return nil
+		}
+	}
+	if suggestion, ok := closestMatch(opt, knownOptions); ok {
+		return fmt.Errorf("unknown config option %q (did you mean %q?)", opt, suggestion)
+	}
+	return fmt.Errorf("unknown config option %q", opt)
+}
+
+func setPrintOptions(args []string, tag *tag) error {
 	if len(args) == 0 {
-		panic("config option \"print\" requires at least one argument")
+		return fmt.Errorf("config option \"print\" requires at least one argument")
 	}
 
 	if len(args) == 1 {
@@ -91,7 +455,6 @@ func setPrintOptions(args []string, tag *tag) {
 
 		case "[nonzero]":
 			tag.PrintMode = printModeNonZero
-			return
 
 		case "[len]":
 			tag.PrintMode = printModeLen
@@ -103,9 +466,12 @@ func setPrintOptions(args []string, tag *tag) {
 			tag.PrintMode = printModeSHA256
 
 		default:
+			if strings.HasPrefix(args[0], "[") && strings.HasSuffix(args[0], "]") {
+				return fmt.Errorf("unknown print mode %q", args[0])
+			}
 			tag.PrintName = args[0]
 		}
-		return
+		return nil
 	}
 
 	if len(args) == 2 {
@@ -113,22 +479,90 @@ func setPrintOptions(args []string, tag *tag) {
 		switch args[1] {
 		case "[nonzero]":
 			tag.PrintMode = printModeNonZero
-			return
+			return nil
 
 		case "[len]":
 			tag.PrintMode = printModeLen
-			return
+			return nil
 
 		case "[mask]":
 			tag.PrintMode = printModeMasked
-			return
+			return nil
 
 		case "[sha256]":
 			tag.PrintMode = printModeSHA256
-			return
+			return nil
+		}
+		return fmt.Errorf("unknown print mode %q", args[1])
+	}
+
+	return fmt.Errorf("too many arguments for config option \"print\"")
+}
+
+func getConstraints(options map[string][]string, columns map[string]int) ([]constraint, error) {
+	var constraints []constraint
+	for _, name := range []string{"nonempty", "url", "hostport"} {
+		if args, ok := options[name]; ok {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("column %d: config option %q does not support any arguments", columns[name], name)
+			}
+			constraints = append(constraints, constraint{name, ""})
+		}
+	}
+
+	for _, name := range []string{"min", "max", "len", "oneof"} {
+		if args, ok := options[name]; ok {
+			if len(args) != 1 || len(args[0]) == 0 {
+				return nil, fmt.Errorf("column %d: config option %q requires exactly one argument", columns[name], name)
+			}
+			constraints = append(constraints, constraint{name, args[0]})
 		}
-		panic(fmt.Sprintf("unknown print mode %q", args[1]))
 	}
 
-	panic("too many arguments for config option \"print\"")
+	if args, ok := options["pattern"]; ok {
+		pattern := strings.Join(args, ":")
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("column %d: config option \"pattern\" contains invalid regular expression: %s", columns["pattern"], err.Error())
+		}
+		constraints = append(constraints, constraint{"pattern", pattern})
+	}
+
+	return constraints, nil
+}
+
+// CheckTags checks the config tags of all struct fields reachable from the types of the given values and returns an error describing all invalid tags.
+//
+// Invalid tags cause a panic when loading or printing a configuration. Call CheckTags in a unit test to detect typos early.
+func CheckTags(types ...interface{}) error {
+	errs := make([]string, 0)
+	visited := make(map[reflect.Type]bool)
+	for _, t := range types {
+		checkTypeTags(&errs, visited, reflect.TypeOf(t))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config tags:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func checkTypeTags(errs *[]string, visited map[reflect.Type]bool, t reflect.Type) {
+	if t == nil || visited[t] {
+		return
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		checkTypeTags(errs, visited, t.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if _, err := parseTag(field); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s.%s: %s", t.String(), field.Name, err.Error()))
+			}
+			checkTypeTags(errs, visited, field.Type)
+		}
+	}
 }
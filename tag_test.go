@@ -8,7 +8,17 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+type TagTestEmbedded struct{}
+
+type TagTestEmbeddedPtr struct{}
+
+type TagTestEmbeddedNamed struct{}
+
 type TagTest struct {
+	TagTestEmbedded
+	*TagTestEmbeddedPtr
+	TagTestEmbeddedNamed `json:"named"`
+
 	None             interface{}
 	Empty            interface{} `config:""`
 	Flags            interface{} `config:"required"`
@@ -26,6 +36,19 @@ type TagTest struct {
 	Default          interface{} `config:"default:some str"`
 	DefaultWithColon interface{} `config:"default:some:nice:str"`
 	FieldName        interface{} `config:"env:Bar,print:Bar,name:Foo"`
+	JSONName         interface{} `json:"other,omitempty" config:"name:Foo"`
+	JSONOptions      interface{} `json:",omitempty" config:"name:Foo"`
+	NoJSON           interface{} `json:"-"`
+	DefaultQuoted    interface{} `config:"default:'a,b:c',print:'Name:With,Colon'"`
+	DefaultEscaped   interface{} `config:"default:a\\,b\\:c\\\\d"`
+	PatternEscaped   interface{} `config:"pattern:^\\d+\\,\\d+$"`
+	Aliases          interface{} `config:"alias:Foo|Bar,deprecated:Old"`
+	Inline           struct{}    `config:"inline"`
+	NoInline         struct{}    `config:"noinline"`
+	ProfileDefault   interface{} `config:"default:a,default.prod:'b:c'"`
+	Encoding         interface{} `config:"encoding:hex"`
+	TimeFormat       interface{} `config:"layout:15:04:05,tz:Europe/Berlin"`
+	Constraints      interface{} `config:"nonempty,min:1,max:10,oneof:a|b,pattern:^[a-z]:[0-9]$"`
 }
 
 type tagTestCase struct {
@@ -34,23 +57,39 @@ type tagTestCase struct {
 }
 
 var tagTestCases = []tagTestCase{
-	{"None", tag{"None", false, printModeDefault, "None", "None", "None", "", false}},
-	{"Empty", tag{"Empty", false, printModeDefault, "Empty", "Empty", "Empty", "", false}},
-	{"Flags", tag{"Flags", true, printModeDefault, "Flags", "Flags", "Flags", "", false}},
-	{"EnvName", tag{"EnvName", false, printModeDefault, "EnvName", "SomeNewName", "EnvName", "", false}},
-	{"NoPrint", tag{"NoPrint", false, printModeNone, "", "NoPrint", "NoPrint", "", false}},
-	{"NonZeroPrint", tag{"NonZeroPrint", false, printModeNonZero, "NonZeroPrint", "NonZeroPrint", "NonZeroPrint", "", false}},
-	{"LenPrint", tag{"LenPrint", false, printModeLen, "LenPrint", "LenPrint", "LenPrint", "", false}},
-	{"MaskedPrint", tag{"MaskedPrint", false, printModeMasked, "MaskedPrint", "MaskedPrint", "MaskedPrint", "", false}},
-	{"HashedPrint", tag{"HashedPrint", false, printModeSHA256, "HashedPrint", "HashedPrint", "HashedPrint", "", false}},
-	{"NonZeroPrintName", tag{"NonZeroPrintName", false, printModeNonZero, "OtherName", "NonZeroPrintName", "NonZeroPrintName", "", false}},
-	{"LenPrintName", tag{"LenPrintName", false, printModeLen, "OtherName", "LenPrintName", "LenPrintName", "", false}},
-	{"MaskedPrintName", tag{"MaskedPrintName", false, printModeMasked, "OtherName", "MaskedPrintName", "MaskedPrintName", "", false}},
-	{"HashedPrintName", tag{"HashedPrintName", false, printModeSHA256, "OtherName", "HashedPrintName", "HashedPrintName", "", false}},
-	{"PrintName", tag{"PrintName", false, printModeDefault, "VisibleName", "PrintName", "PrintName", "", false}},
-	{"Default", tag{"Default", false, printModeDefault, "Default", "Default", "Default", "some str", true}},
-	{"DefaultWithColon", tag{"DefaultWithColon", false, printModeDefault, "DefaultWithColon", "DefaultWithColon", "DefaultWithColon", "some:nice:str", true}},
-	{"FieldName", tag{"Foo", false, printModeDefault, "Bar", "Bar", "Foo", "", false}},
+	{"TagTestEmbedded", tag{FieldName: "TagTestEmbedded", PrintMode: printModeDefault, PrintName: "TagTestEmbedded", EnvName: "TagTestEmbedded", JSONName: "TagTestEmbedded", Inline: true}},
+	{"TagTestEmbeddedPtr", tag{FieldName: "TagTestEmbeddedPtr", PrintMode: printModeDefault, PrintName: "TagTestEmbeddedPtr", EnvName: "TagTestEmbeddedPtr", JSONName: "TagTestEmbeddedPtr", Inline: true}},
+	{"TagTestEmbeddedNamed", tag{FieldName: "TagTestEmbeddedNamed", PrintMode: printModeDefault, PrintName: "TagTestEmbeddedNamed", EnvName: "TagTestEmbeddedNamed", JSONName: "named"}},
+	{"None", tag{FieldName: "None", PrintMode: printModeDefault, PrintName: "None", EnvName: "None", JSONName: "None"}},
+	{"Empty", tag{FieldName: "Empty", PrintMode: printModeDefault, PrintName: "Empty", EnvName: "Empty", JSONName: "Empty"}},
+	{"Flags", tag{FieldName: "Flags", Required: true, PrintMode: printModeDefault, PrintName: "Flags", EnvName: "Flags", JSONName: "Flags"}},
+	{"EnvName", tag{FieldName: "EnvName", PrintMode: printModeDefault, PrintName: "EnvName", EnvName: "SomeNewName", JSONName: "EnvName"}},
+	{"NoPrint", tag{FieldName: "NoPrint", PrintMode: printModeNone, EnvName: "NoPrint", JSONName: "NoPrint"}},
+	{"NonZeroPrint", tag{FieldName: "NonZeroPrint", PrintMode: printModeNonZero, PrintName: "NonZeroPrint", EnvName: "NonZeroPrint", JSONName: "NonZeroPrint"}},
+	{"LenPrint", tag{FieldName: "LenPrint", PrintMode: printModeLen, PrintName: "LenPrint", EnvName: "LenPrint", JSONName: "LenPrint"}},
+	{"MaskedPrint", tag{FieldName: "MaskedPrint", PrintMode: printModeMasked, PrintName: "MaskedPrint", EnvName: "MaskedPrint", JSONName: "MaskedPrint"}},
+	{"HashedPrint", tag{FieldName: "HashedPrint", PrintMode: printModeSHA256, PrintName: "HashedPrint", EnvName: "HashedPrint", JSONName: "HashedPrint"}},
+	{"NonZeroPrintName", tag{FieldName: "NonZeroPrintName", PrintMode: printModeNonZero, PrintName: "OtherName", EnvName: "NonZeroPrintName", JSONName: "NonZeroPrintName"}},
+	{"LenPrintName", tag{FieldName: "LenPrintName", PrintMode: printModeLen, PrintName: "OtherName", EnvName: "LenPrintName", JSONName: "LenPrintName"}},
+	{"MaskedPrintName", tag{FieldName: "MaskedPrintName", PrintMode: printModeMasked, PrintName: "OtherName", EnvName: "MaskedPrintName", JSONName: "MaskedPrintName"}},
+	{"HashedPrintName", tag{FieldName: "HashedPrintName", PrintMode: printModeSHA256, PrintName: "OtherName", EnvName: "HashedPrintName", JSONName: "HashedPrintName"}},
+	{"PrintName", tag{FieldName: "PrintName", PrintMode: printModeDefault, PrintName: "VisibleName", EnvName: "PrintName", JSONName: "PrintName"}},
+	{"Default", tag{FieldName: "Default", PrintMode: printModeDefault, PrintName: "Default", EnvName: "Default", JSONName: "Default", Default: "some str", HasDefault: true}},
+	{"DefaultWithColon", tag{FieldName: "DefaultWithColon", PrintMode: printModeDefault, PrintName: "DefaultWithColon", EnvName: "DefaultWithColon", JSONName: "DefaultWithColon", Default: "some:nice:str", HasDefault: true}},
+	{"FieldName", tag{FieldName: "Foo", PrintMode: printModeDefault, PrintName: "Bar", EnvName: "Bar", JSONName: "Foo"}},
+	{"JSONName", tag{FieldName: "Foo", PrintMode: printModeDefault, PrintName: "Foo", EnvName: "Foo", JSONName: "other"}},
+	{"JSONOptions", tag{FieldName: "Foo", PrintMode: printModeDefault, PrintName: "Foo", EnvName: "Foo", JSONName: "Foo"}},
+	{"NoJSON", tag{FieldName: "NoJSON", PrintMode: printModeDefault, PrintName: "NoJSON", EnvName: "NoJSON", JSONName: "NoJSON", NoJSON: true}},
+	{"DefaultQuoted", tag{FieldName: "DefaultQuoted", PrintMode: printModeDefault, PrintName: "Name:With,Colon", EnvName: "DefaultQuoted", JSONName: "DefaultQuoted", Default: "a,b:c", HasDefault: true}},
+	{"DefaultEscaped", tag{FieldName: "DefaultEscaped", PrintMode: printModeDefault, PrintName: "DefaultEscaped", EnvName: "DefaultEscaped", JSONName: "DefaultEscaped", Default: `a,b:c\d`, HasDefault: true}},
+	{"PatternEscaped", tag{FieldName: "PatternEscaped", PrintMode: printModeDefault, PrintName: "PatternEscaped", EnvName: "PatternEscaped", JSONName: "PatternEscaped", Constraints: []constraint{{"pattern", `^\d+,\d+$`}}}},
+	{"Aliases", tag{FieldName: "Aliases", PrintMode: printModeDefault, PrintName: "Aliases", EnvName: "Aliases", JSONName: "Aliases", Aliases: []fieldAlias{{"Foo", false}, {"Bar", false}, {"Old", true}}}},
+	{"Inline", tag{FieldName: "Inline", PrintMode: printModeDefault, PrintName: "Inline", EnvName: "Inline", JSONName: "Inline", Inline: true}},
+	{"NoInline", tag{FieldName: "NoInline", PrintMode: printModeDefault, PrintName: "NoInline", EnvName: "NoInline", JSONName: "NoInline"}},
+	{"ProfileDefault", tag{FieldName: "ProfileDefault", PrintMode: printModeDefault, PrintName: "ProfileDefault", EnvName: "ProfileDefault", JSONName: "ProfileDefault", Default: "a", HasDefault: true, ProfileDefaults: map[string]string{"prod": "b:c"}}},
+	{"Encoding", tag{FieldName: "Encoding", PrintMode: printModeDefault, PrintName: "Encoding", EnvName: "Encoding", JSONName: "Encoding", Encoding: "hex"}},
+	{"TimeFormat", tag{FieldName: "TimeFormat", PrintMode: printModeDefault, PrintName: "TimeFormat", EnvName: "TimeFormat", JSONName: "TimeFormat", Layout: "15:04:05", TimeZone: "Europe/Berlin"}},
+	{"Constraints", tag{FieldName: "Constraints", PrintMode: printModeDefault, PrintName: "Constraints", EnvName: "Constraints", JSONName: "Constraints", Constraints: []constraint{{"nonempty", ""}, {"min", "1"}, {"max", "10"}, {"oneof", "a|b"}, {"pattern", "^[a-z]:[0-9]$"}}}},
 }
 
 func TestTags(t *testing.T) {
@@ -64,6 +103,89 @@ func TestTags(t *testing.T) {
 	}
 }
 
+type TagTestInvalid struct {
+	Valid    int
+	Default  int                `config:"defualt:5"`
+	Required string             `config:"requried"`
+	Unknown  string             `config:"foobar"`
+	Nested   []*TagTestNested   `config:"print:[unknown]"`
+	Map      map[string]TagTest `config:"name:Map"`
+}
+
+type TagTestNested struct {
+	Parent   *TagTestInvalid
+	Multiple string `config:"env:Foo,env:Bar"`
+}
+
+func TestCheckTags(t *testing.T) {
+	assert.NoError(t, CheckTags(TagTest{}, &EnvTestSimple{}))
+
+	err := CheckTags(&TagTestInvalid{})
+	if assert.Error(t, err) {
+		assert.Equal(t, `invalid config tags:
+config.TagTestInvalid.Default: column 1: unknown config option "defualt" (did you mean "default"?)
+config.TagTestInvalid.Required: column 1: unknown config option "requried" (did you mean "required"?)
+config.TagTestInvalid.Unknown: column 1: unknown config option "foobar"
+config.TagTestInvalid.Nested: column 1: unknown print mode "[unknown]"
+config.TagTestNested.Multiple: column 9: config option "env" specified multiple times`, err.Error())
+	}
+}
+
+func TestTagPanic(t *testing.T) {
+	assert.PanicsWithValue(t, `field Default: column 1: unknown config option "defualt" (did you mean "default"?)`, func() {
+		ToString("Test", TagTestInvalid{})
+	})
+}
+
+func TestTokenizeTag(t *testing.T) {
+	tokens, err := tokenizeTag(`default:'postgres://u:p@h:5432/db?sslmode=disable,a=b',print:-,min:1`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []tagToken{
+			{"default", []string{"postgres://u:p@h:5432/db?sslmode=disable,a=b"}, 1},
+			{"print", []string{"-"}, 56},
+			{"min", []string{"1"}, 64},
+		}, tokens)
+	}
+
+	tokens, err = tokenizeTag(`default:'it\'s',env:'',required`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []tagToken{
+			{"default", []string{"it's"}, 1},
+			{"env", []string{""}, 17},
+			{"required", []string{}, 24},
+		}, tokens)
+	}
+}
+
+func TestTokenizeTagErrors(t *testing.T) {
+	_, err := tokenizeTag(`required,default:'foo`)
+	assert.EqualError(t, err, "column 18: unterminated quote")
+
+	_, err = tokenizeTag(`default:foo\`)
+	assert.EqualError(t, err, "column 12: incomplete escape sequence")
+
+	_, err = tokenizeTag(`required,,env:FOO`)
+	assert.EqualError(t, err, "column 10: missing config option name")
+}
+
+func TestParseTagArgumentErrors(t *testing.T) {
+	cases := map[string]string{
+		`env:FOO,required:yes`:     `column 9: config option "required" does not support any arguments`,
+		`noinline,inline`:          `column 10: config options "inline" and "noinline" are mutually exclusive`,
+		`print:[unknown]`:          `column 1: unknown print mode "[unknown]"`,
+		`default:1,unit:kb`:        `column 11: config option "unit" requires the argument "bytes"`,
+		`encoding:utf8`:            `column 1: unknown encoding "utf8"`,
+		`required,layout:`:         `column 10: config option "layout" requires an argument`,
+		`min:1,max`:                `column 7: config option "max" requires exactly one argument`,
+		`nonempty,url:x`:           `column 10: config option "url" does not support any arguments`,
+		`required,pattern:'^[a-z'`: "column 10: config option \"pattern\" contains invalid regular expression: error parsing regexp: missing closing ]: `[a-z`",
+	}
+	for tagStr, expectedErr := range cases {
+		_, err := parseTag(reflect.StructField{Name: "Field", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`config:"` + tagStr + `"`)})
+		assert.EqualError(t, err, expectedErr, tagStr)
+	}
+}
+
 func getTagForField(fieldName string) tag {
 	var obj TagTest
 	t := reflect.TypeOf(obj)
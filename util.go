@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -14,6 +15,18 @@ type pathPrefix []interface{}
 type fieldName struct {
 	RealName    string
 	VisibleName string
+	// Aliases are alternative visible names of the field.
+	Aliases []fieldAlias
+}
+
+type fieldAlias struct {
+	Name       string
+	Deprecated bool
+}
+
+type pathVariant struct {
+	Path       pathPrefix
+	Deprecated bool
 }
 
 func (p pathPrefix) String() string {
@@ -36,30 +49,65 @@ func (p pathPrefix) String() string {
 	return sb.String()
 }
 
+// Env returns the environment variable name of the path using DefaultEnvNaming.
 func (p pathPrefix) Env() string {
-	var sb strings.Builder
+	return DefaultEnvNaming(p.Parts())
+}
+
+// Parts returns the visible names and indices of the path.
+func (p pathPrefix) Parts() []PathPart {
+	parts := make([]PathPart, len(p))
 	for i, pathPart := range p {
-		if i > 0 && sb.Len() > 0 {
-			sb.WriteString("_")
-		}
 		switch p := pathPart.(type) {
 		case fieldName:
-			sb.WriteString(strings.ToUpper(p.VisibleName))
+			parts[i] = PathPart{Name: p.VisibleName}
 		case int:
-			sb.WriteString(strconv.Itoa(p))
+			parts[i] = PathPart{Index: p, IsIndex: true}
 		default:
 			panic(fmt.Sprintf("invalid path part of type %T", pathPart))
 		}
 	}
-	return sb.String()
+	return parts
+}
+
+// Variants returns the path itself followed by all paths with visible field names replaced by their aliases.
+func (p pathPrefix) Variants() []pathVariant {
+	variants := []pathVariant{{pathPrefix{}, false}}
+	for _, pathPart := range p {
+		nextVariants := make([]pathVariant, 0, len(variants))
+		for _, variant := range variants {
+			nextVariants = append(nextVariants, pathVariant{variant.Path.with(pathPart), variant.Deprecated})
+		}
+
+		if f, ok := pathPart.(fieldName); ok {
+			for _, alias := range f.Aliases {
+				for _, variant := range variants {
+					nextVariants = append(nextVariants, pathVariant{variant.Path.with(fieldName{f.RealName, alias.Name, nil}), variant.Deprecated || alias.Deprecated})
+				}
+			}
+		}
+		variants = nextVariants
+	}
+	return variants
+}
+
+// with returns a copy of the path with the given part appended.
+func (p pathPrefix) with(pathPart interface{}) pathPrefix {
+	path := make(pathPrefix, len(p), len(p)+1)
+	copy(path, p)
+	return append(path, pathPart)
 }
 
 func (p pathPrefix) Field(name string) pathPrefix {
-	return append(p, fieldName{name, name})
+	return append(p, fieldName{name, name, nil})
 }
 
 func (p pathPrefix) Field2(realName, visibleName string) pathPrefix {
-	return append(p, fieldName{realName, visibleName})
+	return append(p, fieldName{realName, visibleName, nil})
+}
+
+func (p pathPrefix) FieldWithAliases(realName, visibleName string, aliases []fieldAlias) pathPrefix {
+	return append(p, fieldName{realName, visibleName, aliases})
 }
 
 func (p pathPrefix) Index(index int) pathPrefix {
@@ -70,7 +118,7 @@ func newPathPrefix(firstField string) pathPrefix {
 	if len(firstField) == 0 {
 		return []interface{}{}
 	}
-	return []interface{}{fieldName{firstField, firstField}}
+	return []interface{}{fieldName{firstField, firstField, nil}}
 }
 
 var (
@@ -129,6 +177,14 @@ func (obj *object) Elem() *object {
 	return &object{obj.t.Elem(), obj.v.Elem()}
 }
 
+// AllocElem allocates a new value for nil pointers if possible and returns the pointed-to value.
+func (obj *object) AllocElem() *object {
+	if obj.v.IsNil() && obj.v.CanSet() {
+		obj.v.Set(reflect.New(obj.t.Elem()))
+	}
+	return obj.Elem()
+}
+
 func (obj *object) Index(i int) *object {
 	return &object{obj.v.Index(i).Type(), obj.v.Index(i)}
 }
@@ -137,12 +193,38 @@ func (obj *object) Interface() interface{} {
 	return obj.v.Interface()
 }
 
-func (obj *object) IterateStruct(f func(obj *object, tag tag) error) error {
-	fieldCount := obj.t.NumField()
-	for i := 0; i < fieldCount; i++ {
-		field := obj.t.Field(i)
-		tag := getTag(field)
-		val := obj.v.Field(i)
+// Implementor returns a pointer to the value if addressable to also find methods with pointer receiver.
+func (obj *object) Implementor() interface{} {
+	if obj.v.CanAddr() && obj.v.Addr().CanInterface() {
+		return obj.v.Addr().Interface()
+	}
+	if obj.v.CanInterface() {
+		return obj.v.Interface()
+	}
+	return nil
+}
+
+// IterateStruct calls f for all fields of the struct and of inlined structs. The tags passed to f are shared and must not be modified.
+func (obj *object) IterateStruct(f func(obj *object, tag *tag) error) error {
+	fields := structFields(obj.t)
+	for i := range fields {
+		tag := &fields[i].Tag
+		val := obj.v.Field(fields[i].Index)
+
+		if tag.Inline {
+			if val.Kind() == reflect.Ptr {
+				if val.IsNil() {
+					// loaders allocate nil pointers with AllocInline before
+					continue
+				}
+				val = val.Elem()
+			}
+			// fields of inlined structs are visited as if they belong to this struct
+			if err := (&object{val.Type(), val}).IterateStruct(f); err != nil {
+				return err
+			}
+			continue
+		}
 
 		if err := f(&object{val.Type(), val}, tag); err != nil {
 			return err
@@ -151,6 +233,62 @@ func (obj *object) IterateStruct(f func(obj *object, tag tag) error) error {
 	return nil
 }
 
+// AllocInline allocates all nil pointers to inlined structs, so IterateStruct visits their fields. The returned function resets the pointers to nil again if no value has been assigned, like for optional values.
+func (obj *object) AllocInline() func() {
+	if !hasInlinePointers(obj.t) {
+		return releaseNothing
+	}
+
+	var allocated []reflect.Value
+	obj.allocInline(&allocated)
+	return func() {
+		// nested pointers are reset first to detect empty parents
+		for i := len(allocated) - 1; i >= 0; i-- {
+			if allocated[i].Elem().IsZero() {
+				allocated[i].Set(reflect.Zero(allocated[i].Type()))
+			}
+		}
+	}
+}
+
+func releaseNothing() {}
+
+// hasInlinePointers returns true if the struct type t contains pointers to inlined structs at any level.
+func hasInlinePointers(t reflect.Type) bool {
+	fields := structFields(t)
+	for i := range fields {
+		if !fields[i].Tag.Inline {
+			continue
+		}
+		if fields[i].Type.Kind() == reflect.Ptr || hasInlinePointers(fields[i].Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *object) allocInline(allocated *[]reflect.Value) {
+	fields := structFields(obj.t)
+	for i := range fields {
+		if !fields[i].Tag.Inline {
+			continue
+		}
+
+		val := obj.v.Field(fields[i].Index)
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				if !val.CanSet() {
+					continue
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+				*allocated = append(*allocated, val)
+			}
+			val = val.Elem()
+		}
+		(&object{val.Type(), val}).allocInline(allocated)
+	}
+}
+
 func (obj *object) IterateArray(f func(i int, obj *object) error) error {
 	len := obj.v.Len()
 	for i := 0; i < len; i++ {
@@ -200,25 +338,107 @@ func (obj *object) SetIntFromString(strVal string) error {
 	return obj.SetInt(val)
 }
 
+func (obj *object) SetByteSizeFromString(strVal string) error {
+	size, err := ParseByteSize(strVal)
+	if err != nil {
+		return err
+	}
+	if obj.v.OverflowInt(int64(size)) {
+		return fmt.Errorf("byte size %q exceeds range of %s", strVal, obj.t)
+	}
+	obj.v.SetInt(int64(size))
+	return nil
+}
+
+// StringSetter returns the function to parse a single string into obj according to tag, or nil if obj is composed of multiple values.
+func (obj *object) StringSetter(fieldTag *tag) func(string) error {
+	var t tag
+	if fieldTag != nil {
+		t = *fieldTag
+	}
+
+	switch {
+	case obj.Is(typeDateTime):
+		return func(strVal string) error {
+			return obj.SetDateTimeFromStringWithFormat(strVal, t.Layout, t.TimeZone)
+		}
+	case obj.Is(typeDuration):
+		if t.Calendar {
+			return obj.SetCalendarDurationFromString
+		}
+		return obj.SetDurationFromString
+	case obj.Is(typeByteSize) || (t.Unit == unitBytes && obj.Kind() == reflect.Int):
+		return obj.SetByteSizeFromString
+	case isEnumType(obj.t):
+		return obj.SetEnumFromString
+	case isNetworkType(obj.t):
+		return obj.SetNetworkValueFromString
+	case isByteSlice(obj.t):
+		return func(strVal string) error {
+			return obj.SetBytesFromString(strVal, t.Encoding)
+		}
+	}
+
+	switch obj.Kind() {
+	case reflect.String:
+		return obj.SetString
+	case reflect.Bool:
+		return obj.SetBoolFromString
+	case reflect.Int:
+		return obj.SetIntFromString
+	}
+	return nil
+}
+
+func (obj *object) SetEnumFromString(strVal string) error {
+	enum, ok := lookupEnum(obj.t)
+	if !ok {
+		return fmt.Errorf("no enum registered for type %s", obj.t)
+	}
+	val, err := enum.Parse(strVal)
+	if err != nil {
+		return err
+	}
+	obj.v.Set(val)
+	return nil
+}
+
+func (obj *object) SetNetworkValueFromString(strVal string) error {
+	val, err := parseNetworkValue(obj.t, strVal)
+	if err != nil {
+		return err
+	}
+	obj.v.Set(reflect.ValueOf(val))
+	return nil
+}
+
+func (obj *object) SetBytesFromString(strVal, encoding string) error {
+	data, err := decodeBytes(strVal, encoding)
+	if err != nil {
+		return err
+	}
+	obj.v.SetBytes(data)
+	return nil
+}
+
 func (obj *object) SetDateTimeFromString(strVal string) error {
-	dt, err := func() (time.Time, error) {
-		strVal = strings.ReplaceAll(strVal, " ", "T")
-		switch len(strVal) {
-		case 10:
-			return time.ParseInLocation("2006-01-02", strVal, time.Local)
-		case 19:
-			return time.ParseInLocation("2006-01-02T15:04:05", strVal, time.Local)
-		case 20:
-			return time.ParseInLocation("2006-01-02T15:04:05Z", strVal, time.UTC)
-		case 24:
-			return time.ParseInLocation("2006-01-02T15:04:05-0700", strVal, time.UTC)
-		case 25:
-			return time.ParseInLocation("2006-01-02T15:04:05-0700", strVal[:22]+strVal[23:], time.UTC)
-		}
-		return time.Time{}, fmt.Errorf("invalid format")
-	}()
+	return obj.SetDateTimeFromStringWithFormat(strVal, "", "")
+}
+
+// SetDateTimeFromStringWithFormat parses the value according to layout and interprets values without time zone in the location named by timeZone. The local time zone is used if timeZone is empty.
+func (obj *object) SetDateTimeFromStringWithFormat(strVal, layout, timeZone string) error {
+	loc := time.Local
+	if len(timeZone) > 0 {
+		var err error
+		loc, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return fmt.Errorf("unknown time zone %q", timeZone)
+		}
+	}
+
+	dt, err := parseDateTime(strVal, layout, loc)
 	if err != nil {
-		return fmt.Errorf("cannot parse datetime from %q", strVal)
+		return fmt.Errorf("cannot parse datetime from %q: %s", strVal, err.Error())
 	}
 
 	obj.v.Set(reflect.ValueOf(dt))
@@ -226,6 +446,32 @@ func (obj *object) SetDateTimeFromString(strVal string) error {
 }
 
 func (obj *object) SetDurationFromString(strVal string) error {
+	return obj.setDurationFromString(strVal, false)
+}
+
+// SetCalendarDurationFromString uses the average length of years and months in the gregorian calendar.
+func (obj *object) SetCalendarDurationFromString(strVal string) error {
+	return obj.setDurationFromString(strVal, true)
+}
+
+func (obj *object) setDurationFromString(strVal string, calendar bool) error {
+	d, err := parseDuration(strVal, calendar)
+	if err != nil {
+		return err
+	}
+
+	obj.v.Set(reflect.ValueOf(d))
+	return nil
+}
+
+var (
+	durationPattern = regexp.MustCompile(`[0-9]+[.,][0-9]*|[0-9]*[.,]?[0-9]+|ms|us|µs|μs|ns|[^0-9\s]`)
+)
+
+// parseDuration parses ISO 8601 durations like P1DT1.5H, abbreviations like 1y 4d 13m and all formats of time.ParseDuration.
+//
+// Years and months are 365 and 30 days long, or the average length in the gregorian calendar if calendar is set.
+func parseDuration(strVal string, calendar bool) (time.Duration, error) {
 	d, err := func() (time.Duration, error) {
 		second := time.Second
 		minute := time.Minute
@@ -234,52 +480,80 @@ func (obj *object) SetDurationFromString(strVal string) error {
 		week := 7 * day
 		month := 30 * day
 		year := 365 * day
+		if calendar {
+			year = 365*day + 5*hour + 49*minute + 12*second
+			month = year / 12
+		}
 
-		var d time.Duration
+		str := strings.TrimSpace(strVal)
+		negative := false
+		if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+			negative = str[0] == '-'
+			str = str[1:]
+		}
+		if str == "0" {
+			// compatibility to time.ParseDuration
+			return 0, nil
+		}
+
+		// sum up as exact fraction to support fractional values and detect overflows
+		sum := new(big.Rat)
+		add := func(num *big.Rat, unit time.Duration) {
+			sum.Add(sum, num.Mul(num, new(big.Rat).SetInt64(int64(unit))))
+		}
 
 		// parse ISO 8601 duration
 
 		periodMode := false
-		lastNum := -1
-
-		pattern := regexp.MustCompile(`[0-9]+|[^0-9\s]`)
-		tokens := pattern.FindAllString(strVal, -1)
+		var lastNum *big.Rat
 
-		for _, token := range tokens {
-			if lastNum >= 0 {
+		for _, token := range durationPattern.FindAllString(str, -1) {
+			if lastNum != nil {
 				// expect time designator after numeric value
-				if periodMode {
-					switch strings.ToUpper(token) {
-					case "Y":
-						d += time.Duration(lastNum) * year
-					case "M":
-						d += time.Duration(lastNum) * month
-					case "W":
-						d += time.Duration(lastNum) * week
-					case "D":
-						d += time.Duration(lastNum) * day
-					default:
-						return 0, fmt.Errorf("unknown period designator %q", token)
-					}
-				} else {
-					switch strings.ToUpper(token) {
-					case "H":
-						d += time.Duration(lastNum) * hour
-					case "M":
-						d += time.Duration(lastNum) * minute
-					case "S":
-						d += time.Duration(lastNum) * second
-						// also accept some period designators for abbreviation
-					case "Y":
-						d += time.Duration(lastNum) * year
-					case "D":
-						d += time.Duration(lastNum) * day
-					default:
-						return 0, fmt.Errorf("unknown time designator %q", token)
+				switch token {
+				case "ns":
+					add(lastNum, time.Nanosecond)
+				case "us", "µs", "μs":
+					add(lastNum, time.Microsecond)
+				case "ms":
+					add(lastNum, time.Millisecond)
+
+				default:
+					if periodMode {
+						switch strings.ToUpper(token) {
+						case "Y":
+							add(lastNum, year)
+						case "M":
+							add(lastNum, month)
+						case "W":
+							add(lastNum, week)
+						case "D":
+							add(lastNum, day)
+						default:
+							return 0, fmt.Errorf("unknown period designator %q", token)
+						}
+					} else {
+						switch strings.ToUpper(token) {
+						case "H":
+							add(lastNum, hour)
+						case "M":
+							add(lastNum, minute)
+						case "S":
+							add(lastNum, second)
+							// also accept some period designators for abbreviation
+						case "Y":
+							add(lastNum, year)
+						case "W":
+							add(lastNum, week)
+						case "D":
+							add(lastNum, day)
+						default:
+							return 0, fmt.Errorf("unknown time designator %q", token)
+						}
 					}
 				}
 				// update parser state to read period modifier or numeric value in next step
-				lastNum = -1
+				lastNum = nil
 
 			} else {
 				switch strings.ToUpper(token) {
@@ -288,8 +562,9 @@ func (obj *object) SetDurationFromString(strVal string) error {
 				case "P":
 					periodMode = true
 				default:
-					num, err := strconv.Atoi(token)
-					if err != nil {
+					// ISO 8601 also allows a comma as decimal separator
+					num, ok := new(big.Rat).SetString(strings.Replace(token, ",", ".", 1))
+					if !ok {
 						return 0, fmt.Errorf("cannot parse numeric value from %q", token)
 					}
 					lastNum = num
@@ -297,20 +572,132 @@ func (obj *object) SetDurationFromString(strVal string) error {
 			}
 		}
 
-		if lastNum >= 0 {
+		if lastNum != nil {
 			return 0, fmt.Errorf("missing designator")
 		}
 
-		return d, nil
+		if negative {
+			sum.Neg(sum)
+		}
+		// fractions of nanoseconds are truncated like in time.ParseDuration
+		nanos := new(big.Int).Quo(sum.Num(), sum.Denom())
+		if !nanos.IsInt64() {
+			return 0, fmt.Errorf("duration out of range")
+		}
+		return time.Duration(nanos.Int64()), nil
 	}()
 	if err != nil {
-		return fmt.Errorf("cannot parse duration from %q: %s", strVal, err.Error())
+		return 0, fmt.Errorf("cannot parse duration from %q: %s", strVal, err.Error())
 	}
-
-	obj.v.Set(reflect.ValueOf(d))
-	return nil
+	return d, nil
 }
 
 func newObject(obj interface{}) *object {
 	return &object{reflect.TypeOf(obj), reflect.ValueOf(obj)}
 }
+
+// deepCopy returns a copy of v that shares no pointers, slices or maps with v. Unexported fields are copied shallowly.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(deepCopy(v.Elem()))
+		return cp
+
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if cp.Field(i).CanSet() {
+				cp.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return cp
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return cp
+
+	default:
+		return v
+	}
+}
+
+// closestMatch returns the candidate with the smallest edit distance to str, if it is similar enough to be a likely typo.
+func closestMatch(str string, candidates []string) (string, bool) {
+	bestMatch := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(str), strings.ToLower(candidate))
+		if bestDistance < 0 || distance < bestDistance {
+			bestMatch = candidate
+			bestDistance = distance
+		}
+	}
+
+	maxDistance := len(str) / 3
+	if maxDistance > 3 {
+		maxDistance = 3
+	}
+	if bestDistance < 0 || bestDistance > maxDistance {
+		return "", false
+	}
+	return bestMatch, true
+}
+
+// levenshtein returns the number of single character edits needed to transform a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -18,3 +20,97 @@ func TestPathPrefix(t *testing.T) {
 	assert.Equal(t, "Test.SubItem[2].Num", newPathPrefix("Test").Field2("SubItem", "Item").Index(2).Field("Num").String())
 	assert.Equal(t, "TEST_ITEM_2_NUM", newPathPrefix("Test").Field2("SubItem", "Item").Index(2).Field("Num").Env())
 }
+
+func TestPathPrefixVariants(t *testing.T) {
+	prefix := newPathPrefix("Test").FieldWithAliases("DB", "DB", []fieldAlias{{"Database", false}}).FieldWithAliases("Address", "Address", []fieldAlias{{"Addr", true}})
+	variants := prefix.Variants()
+	keys := make([]string, len(variants))
+	deprecated := make([]bool, len(variants))
+	for i, v := range variants {
+		keys[i] = v.Path.Env()
+		deprecated[i] = v.Deprecated
+	}
+	assert.Equal(t, []string{"TEST_DB_ADDRESS", "TEST_DATABASE_ADDRESS", "TEST_DB_ADDR", "TEST_DATABASE_ADDR"}, keys)
+	assert.Equal(t, []bool{false, false, true, true}, deprecated)
+	assert.Equal(t, "Test.DB.Address", variants[3].Path.String())
+}
+
+func TestParseDurationGoSyntax(t *testing.T) {
+	for _, str := range []string{"0", "1h30m", "1.5h", "250ms", "-5m", "+5m", "1µs", "1μs", "1us", "100ns", ".5s", "1.s", "2h45m30.5s", "-1.5h", "1m0.000000001s", "9223372036854775807ns", "-9223372036854775808ns"} {
+		expected, err := time.ParseDuration(str)
+		if !assert.NoError(t, err, str) {
+			continue
+		}
+		d, err := parseDuration(str, false)
+		if assert.NoError(t, err, str) {
+			assert.Equal(t, expected, d, str)
+		}
+	}
+}
+
+func TestParseDurationISO8601(t *testing.T) {
+	day := 24 * time.Hour
+	testCases := map[string]time.Duration{
+		"PT0.5S":     500 * time.Millisecond,
+		"PT0,5S":     500 * time.Millisecond,
+		"P1.5D":      36 * time.Hour,
+		"-P1D":       -day,
+		"P1W":        7 * day,
+		"P1Y2M":      365*day + 60*day,
+		"PT1H30.25M": time.Hour + 30*time.Minute + 15*time.Second,
+		"1y 4d 13m":  369*day + 13*time.Minute,
+		"1d 250ms":   day + 250*time.Millisecond,
+		"":           0,
+	}
+	for str, expected := range testCases {
+		d, err := parseDuration(str, false)
+		if assert.NoError(t, err, str) {
+			assert.Equal(t, expected, d, str)
+		}
+	}
+}
+
+func TestParseDurationCalendar(t *testing.T) {
+	year := 365*24*time.Hour + 5*time.Hour + 49*time.Minute + 12*time.Second
+	d, err := parseDuration("P1Y", true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, year, d)
+	}
+	d, err = parseDuration("P1M", true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, year/12, d)
+	}
+}
+
+func TestParseDurationErrors(t *testing.T) {
+	for _, str := range []string{"1", "5x", "1h-5m", "--5m", "h", "1.2.3s", "300y", "9223372036854775808ns", "P1H"} {
+		_, err := parseDuration(str, false)
+		assert.Error(t, err, str)
+	}
+}
+
+type DeepCopyTest struct {
+	Ptr     *int
+	Slice   []string
+	Map     map[string][]int
+	Array   [2]*int
+	private *int
+}
+
+func TestDeepCopy(t *testing.T) {
+	one, two := 1, 2
+	orig := DeepCopyTest{&one, []string{"a"}, map[string][]int{"x": {1}}, [2]*int{&two, nil}, &one}
+	cp := deepCopy(reflect.ValueOf(orig)).Interface().(DeepCopyTest)
+	assert.Equal(t, orig, cp)
+
+	*cp.Ptr = 3
+	cp.Slice[0] = "b"
+	cp.Map["x"][0] = 2
+	*cp.Array[0] = 4
+	assert.Equal(t, 1, *orig.Ptr)
+	assert.Equal(t, "a", orig.Slice[0])
+	assert.Equal(t, 1, orig.Map["x"][0])
+	assert.Equal(t, 2, *orig.Array[0])
+	// unexported fields are shared
+	assert.Same(t, orig.private, cp.private)
+}
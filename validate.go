@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a configuration value that violates a constraint.
+type ValidationError struct {
+	// Path denotes the value in the configuration hierarchy.
+	Path string
+	// Env is the environment variable to configure the value. It is empty for values not read from environment.
+	Env     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	if len(e.Env) > 0 {
+		return fmt.Sprintf("%s: %s (set %s)", e.Path, e.Message, e.Env)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors contains all constraint violations of a configuration.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks all constraints defined by config tags and calls Validator implementations of all structs. It returns ValidationErrors containing all violations.
+//
+// Validation is performed automatically by FromEnvironment and FromJSON unless WithoutValidation is given. The prefix and the naming strategy given by WithEnvNaming are used to name environment variables in errors.
+func Validate(prefix string, conf interface{}, opts ...Option) error {
+	ctx := newLoadContext(opts)
+	return validate(newPathPrefix(prefix), newObject(conf), ctx.Options.EnvNaming)
+}
+
+// validate checks all constraints of obj. Environment variables are only named in errors if envNaming is set.
+func validate(prefix pathPrefix, obj *object, envNaming EnvNaming) error {
+	if obj.t == nil || !getTypeInfo(obj.t).Validated {
+		// nothing to check
+		return nil
+	}
+
+	errs := make(ValidationErrors, 0)
+	validateObject(&errs, prefix, obj, nil, envNaming)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateObject(errs *ValidationErrors, prefix pathPrefix, obj *object, tag *tag, envNaming EnvNaming) {
+	if tag != nil {
+		for _, c := range tag.Constraints {
+			if msg, ok := checkConstraint(obj, c); !ok {
+				err := &ValidationError{Path: prefix.String(), Message: msg}
+				if envNaming != nil {
+					err.Env = envNaming(prefix.Parts())
+				}
+				*errs = append(*errs, err)
+			}
+		}
+	}
+
+	if obj.Is(typeDateTime) || obj.Is(typeDuration) || isNetworkType(obj.t) {
+		return
+	}
+
+	switch obj.Kind() {
+	case reflect.Ptr:
+		if !obj.IsNil() {
+			validateObject(errs, prefix, obj.Elem(), nil, envNaming)
+		}
+
+	case reflect.Struct:
+		validateStruct(errs, prefix, obj, envNaming)
+
+	case reflect.Slice, reflect.Array:
+		obj.IterateArray(func(i int, obj *object) error {
+			validateObject(errs, prefix.Index(i), obj, nil, envNaming)
+			return nil
+		})
+	}
+}
+
+func validateStruct(errs *ValidationErrors, prefix pathPrefix, obj *object, envNaming EnvNaming) {
+	obj.IterateStruct(func(obj *object, tag *tag) error {
+		if obj.IsReadable() {
+			validateObject(errs, prefix.Field2(tag.FieldName, tag.EnvName), obj, tag, envNaming)
+		}
+		return nil
+	})
+
+	if err := callValidate(obj); err != nil {
+		*errs = append(*errs, &ValidationError{Path: prefix.String(), Message: err.Error()})
+	}
+}
+
+// checkConstraint returns a message describing the violation if obj does not satisfy the constraint.
+func checkConstraint(obj *object, c constraint) (string, bool) {
+	v := obj.v
+	if v.Kind() == reflect.Ptr && c.Name != "nonempty" {
+		if v.IsNil() {
+			// constraints only apply to present values
+			return "", true
+		}
+		v = v.Elem()
+	}
+
+	switch c.Name {
+	case "nonempty":
+		if v.IsZero() {
+			return "must not be empty", false
+		}
+		return "", true
+
+	case "min", "max", "len":
+		return checkBound(v, c)
+
+	case "oneof", "pattern", "url", "hostport":
+		// format constraints only apply to non-empty values, combine with nonempty to make them mandatory
+		if v.IsZero() {
+			return "", true
+		}
+		return checkFormat(v, c)
+
+	default:
+		panic(fmt.Sprintf("unknown constraint %q", c.Name))
+	}
+}
+
+func checkBound(v reflect.Value, c constraint) (string, bool) {
+	var cmp int
+	var what string
+
+	switch {
+	case v.Type() == typeDuration:
+		bound, err := parseDuration(c.Arg, false)
+		if err != nil || c.Name == "len" {
+			return fmt.Sprintf("invalid constraint %s:%s for duration", c.Name, c.Arg), false
+		}
+		cmp = compareInt64(v.Int(), int64(bound))
+		what = "be"
+
+	case v.Type() == typeByteSize:
+		bound, err := ParseByteSize(c.Arg)
+		if err != nil || c.Name == "len" {
+			return fmt.Sprintf("invalid constraint %s:%s for byte size", c.Name, c.Arg), false
+		}
+		cmp = compareInt64(v.Int(), int64(bound))
+		what = "be"
+
+	case v.Kind() == reflect.String || v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map:
+		bound, err := strconv.Atoi(c.Arg)
+		if err != nil {
+			return fmt.Sprintf("invalid constraint %s:%s for length", c.Name, c.Arg), false
+		}
+		length := v.Len()
+		if v.Kind() == reflect.String {
+			length = utf8.RuneCountInString(v.String())
+		}
+		cmp = compareInt64(int64(length), int64(bound))
+		what = "have a length of"
+
+	case isIntKind(v.Kind()) && c.Name != "len":
+		bound, err := strconv.ParseInt(c.Arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid constraint %s:%s for integer", c.Name, c.Arg), false
+		}
+		cmp = compareInt64(v.Int(), bound)
+		what = "be"
+
+	case isUintKind(v.Kind()) && c.Name != "len":
+		bound, err := strconv.ParseUint(c.Arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid constraint %s:%s for unsigned integer", c.Name, c.Arg), false
+		}
+		cmp = compareUint64(v.Uint(), bound)
+		what = "be"
+
+	case (v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64) && c.Name != "len":
+		bound, err := strconv.ParseFloat(c.Arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid constraint %s:%s for float", c.Name, c.Arg), false
+		}
+		cmp = compareFloat64(v.Float(), bound)
+		what = "be"
+
+	default:
+		return fmt.Sprintf("constraint %s not supported for type %s", c.Name, v.Type()), false
+	}
+
+	switch c.Name {
+	case "min":
+		if cmp < 0 {
+			return fmt.Sprintf("must %s at least %s", what, c.Arg), false
+		}
+	case "max":
+		if cmp > 0 {
+			return fmt.Sprintf("must %s at most %s", what, c.Arg), false
+		}
+	case "len":
+		if cmp != 0 {
+			return fmt.Sprintf("must %s exactly %s", what, c.Arg), false
+		}
+	}
+	return "", true
+}
+
+func checkFormat(v reflect.Value, c constraint) (string, bool) {
+	if c.Name == "oneof" {
+		str := fmt.Sprintf("%v", v.Interface())
+		options := strings.Split(c.Arg, "|")
+		for _, option := range options {
+			if str == option {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of %s, but is %q", strings.Join(options, ", "), str), false
+	}
+
+	if v.Kind() != reflect.String {
+		return fmt.Sprintf("constraint %s not supported for type %s", c.Name, v.Type()), false
+	}
+	str := v.String()
+
+	switch c.Name {
+	case "pattern":
+		if !regexp.MustCompile(c.Arg).MatchString(str) {
+			return fmt.Sprintf("must match pattern %q", c.Arg), false
+		}
+
+	case "url":
+		u, err := url.Parse(str)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+			return fmt.Sprintf("must be an absolute url, but is %q", redactURL(str)), false
+		}
+
+	case "hostport":
+		_, port, err := net.SplitHostPort(str)
+		if err != nil {
+			return fmt.Sprintf("must be of format host:port, but is %q", str), false
+		}
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return fmt.Sprintf("must contain a port between 1 and 65535, but is %q", str), false
+		}
+	}
+	return "", true
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind == reflect.Int || kind == reflect.Int8 || kind == reflect.Int16 || kind == reflect.Int32 || kind == reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind == reflect.Uint || kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32 || kind == reflect.Uint64 || kind == reflect.Uintptr
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
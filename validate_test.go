@@ -0,0 +1,140 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ValidateTestSimple struct {
+	Port     int           `config:"min:1,max:65535"`
+	Level    string        `config:"oneof:debug|info|warn"`
+	Name     string        `config:"nonempty,pattern:^[a-z]+$"`
+	Token    string        `config:"len:4"`
+	Endpoint string        `config:"url"`
+	Listen   string        `config:"hostport"`
+	Hosts    []string      `config:"max:2"`
+	Timeout  time.Duration `config:"min:1s,max:1m"`
+	Ratio    *int          `config:"max:100"`
+}
+
+func TestValidateValid(t *testing.T) {
+	conf := ValidateTestSimple{
+		Port:     8080,
+		Level:    "info",
+		Name:     "foobar",
+		Token:    "abcd",
+		Endpoint: "https://example.com/path",
+		Listen:   ":8080",
+		Hosts:    []string{"a", "b"},
+		Timeout:  30 * time.Second,
+	}
+	assert.NoError(t, Validate("Main", &conf))
+}
+
+func TestValidateInvalid(t *testing.T) {
+	ratio := 101
+	conf := ValidateTestSimple{
+		Port:     0,
+		Level:    "trace",
+		Name:     "Foo",
+		Token:    "abc",
+		Endpoint: "example.com",
+		Listen:   "localhost",
+		Hosts:    []string{"a", "b", "c"},
+		Timeout:  2 * time.Minute,
+		Ratio:    &ratio,
+	}
+
+	err := Validate("Main", &conf)
+	require.IsType(t, ValidationErrors{}, err)
+	assert.Equal(t, []string{
+		"Main.Port: must be at least 1 (set MAIN_PORT)",
+		"Main.Level: must be one of debug, info, warn, but is \"trace\" (set MAIN_LEVEL)",
+		"Main.Name: must match pattern \"^[a-z]+$\" (set MAIN_NAME)",
+		"Main.Token: must have a length of exactly 4 (set MAIN_TOKEN)",
+		"Main.Endpoint: must be an absolute url, but is \"example.com\" (set MAIN_ENDPOINT)",
+		"Main.Listen: must be of format host:port, but is \"localhost\" (set MAIN_LISTEN)",
+		"Main.Hosts: must have a length of at most 2 (set MAIN_HOSTS)",
+		"Main.Timeout: must be at most 1m (set MAIN_TIMEOUT)",
+		"Main.Ratio: must be at most 100 (set MAIN_RATIO)",
+	}, errorLines(err.(ValidationErrors)))
+}
+
+func TestValidateEmpty(t *testing.T) {
+	var conf ValidateTestSimple
+	conf.Port = 80
+	conf.Timeout = time.Second
+
+	err := Validate("Main", &conf)
+	require.IsType(t, ValidationErrors{}, err)
+	assert.Equal(t, []string{
+		"Main.Name: must not be empty (set MAIN_NAME)",
+		"Main.Token: must have a length of exactly 4 (set MAIN_TOKEN)",
+	}, errorLines(err.(ValidationErrors)))
+}
+
+type ValidateTestNested struct {
+	Nested ValidateTestSimple `config:"env:SUB"`
+}
+
+func TestValidateFromEnvironment(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		env["TEST_SUB_PORT"] = "70000"
+		env["TEST_SUB_NAME"] = "foobar"
+		env["TEST_SUB_TOKEN"] = "abcd"
+		env["TEST_SUB_TIMEOUT"] = "5s"
+
+		var conf ValidateTestNested
+		err := FromEnvironment("test", &conf)
+		if assert.Error(t, err) {
+			assert.Equal(t, "test.Nested.Port: must be at most 65535 (set TEST_SUB_PORT)", err.Error())
+		}
+	})
+}
+
+func TestValidateFromJSON(t *testing.T) {
+	var conf ValidateTestSimple
+	err := FromJSON([]byte(`{"Port":80,"Name":"foobar","Token":"abcd","Timeout":"5s","Level":"error"}`), &conf)
+	if assert.Error(t, err) {
+		assert.Equal(t, "Level: must be one of debug, info, warn, but is \"error\"", err.Error())
+	}
+}
+
+func TestValidateURLRedactsPassword(t *testing.T) {
+	conf := ValidateTestSimple{Port: 80, Name: "foobar", Token: "abcd", Timeout: time.Second, Endpoint: "user:secret@db:5432/app"}
+	err := Validate("Main", &conf)
+	if assert.Error(t, err) {
+		assert.Equal(t, "Main.Endpoint: must be an absolute url, but is \"user:xxxxx@db:5432/app\" (set MAIN_ENDPOINT)", err.Error())
+	}
+}
+
+type ValidateTestLayered struct {
+	Host   string `config:"nonempty"`
+	Secret string `config:"nonempty"`
+}
+
+func TestValidateLayered(t *testing.T) {
+	withMockEnv(func(env map[string]string) {
+		var conf ValidateTestLayered
+		require.Error(t, FromJSON([]byte(`{"Host":"db"}`), &conf))
+
+		conf = ValidateTestLayered{}
+		require.NoError(t, FromJSON([]byte(`{"Host":"db"}`), &conf, WithoutValidation()))
+		require.Error(t, FromEnvironment("test", &conf))
+
+		env["TEST_SECRET"] = "foobar"
+		require.NoError(t, FromEnvironment("test", &conf))
+		assert.Equal(t, ValidateTestLayered{"db", "foobar"}, conf)
+	})
+}
+
+func errorLines(errs ValidationErrors) []string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return lines
+}